@@ -0,0 +1,145 @@
+package fetch
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BasicAuth sets the Authorization header with the username and password.
+func BasicAuth(username, password string) Interceptor {
+	return func(req *http.Request, next Next) (*http.Response, error) {
+		req.SetBasicAuth(username, password)
+		return next(req)
+	}
+}
+
+// BearerAuth sets the Authorization header with the bearer token.
+func BearerAuth(token string) Interceptor {
+	return func(req *http.Request, next Next) (*http.Response, error) {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return next(req)
+	}
+}
+
+// APIKeyHeader sends the key in the header with the name e.g. X-API-Key.
+func APIKeyHeader(name, key string) Interceptor {
+	return func(req *http.Request, next Next) (*http.Response, error) {
+		req.Header.Set(name, key)
+		return next(req)
+	}
+}
+
+// APIKeyQuery sends the key in the query parameter with the name e.g. api_key.
+func APIKeyQuery(name, key string) Interceptor {
+	return func(req *http.Request, next Next) (*http.Response, error) {
+		q := req.URL.Query()
+		q.Set(name, key)
+		req.URL.RawQuery = q.Encode()
+		return next(req)
+	}
+}
+
+/*
+OAuth2ClientCredentials gets access tokens with the OAuth2 client credentials grant.
+The token is cached until it is about to expire. If the server responds with 401,
+the token is renewed and the request is sent again once.
+Use its Intercept method as Auth.
+e.g.
+
+	oauth := &fetch.OAuth2ClientCredentials{
+		TokenURL:     "https://auth.example.com/oauth/token",
+		ClientID:     "my-service",
+		ClientSecret: os.Getenv("CLIENT_SECRET"),
+	}
+	petstore := &fetch.Client{BaseURL: "https://petstore.swagger.io/v2", Auth: oauth.Intercept}
+*/
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// The token is renewed this long before it expires. Defaults to 10 seconds.
+	ExpiryDelta time.Duration
+	// HttpClient requesting the tokens. Defaults to http.DefaultClient.
+	HttpClient *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (o *OAuth2ClientCredentials) Intercept(req *http.Request, next Next) (*http.Response, error) {
+	token, err := o.getToken(req, "")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err := next(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	// a streamed body can't be sent again.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return res, nil
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	token, err = o.getToken(req, token)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetBody != nil {
+		req.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return next(req)
+}
+
+// getToken returns the cached token or requests a new one if it's expired or it's the rejected one.
+// The requests rejected together renew the token once, the others get the renewed token.
+func (o *OAuth2ClientCredentials) getToken(req *http.Request, rejected string) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delta := o.ExpiryDelta
+	if delta == 0 {
+		delta = 10 * time.Second
+	}
+	if o.token != "" && o.token != rejected && (o.expires.IsZero() || time.Now().Add(delta).Before(o.expires)) {
+		return o.token, nil
+	}
+	form := map[string]string{"grant_type": "client_credentials"}
+	if len(o.Scopes) > 0 {
+		form["scope"] = strings.Join(o.Scopes, " ")
+	}
+	c := &Client{HttpClient: o.HttpClient}
+	token, err := PostWith[oauth2Token](c, o.TokenURL, Form{Value: form}, Config{
+		Ctx:          req.Context(),
+		Interceptors: []Interceptor{BasicAuth(o.ClientID, o.ClientSecret)},
+		Headers:      map[string]string{"Accept": "application/json"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("oauth2 token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("oauth2 token: no access_token in response")
+	}
+	o.token = token.AccessToken
+	o.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		o.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return o.token, nil
+}
@@ -0,0 +1,142 @@
+package fetch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAuth_Static(t *testing.T) {
+	m := &Mock{}
+	m.On("*", "*").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(req, 200, nil, []byte(req.Header.Get("Authorization")+"|"+req.Header.Get("X-API-Key")+"|"+req.URL.RawQuery)), nil
+	})
+	c := &Client{HttpClient: m.HttpClient(), Auth: BearerAuth("token")}
+
+	cases := []struct {
+		auth Interceptor
+		want string
+	}{
+		{auth: nil, want: "Bearer token||page=1"},
+		{auth: BasicAuth("lola", "secret"), want: "Basic bG9sYTpzZWNyZXQ=||page=1"},
+		{auth: APIKeyHeader("X-API-Key", "key"), want: "|key|page=1"},
+		{auth: APIKeyQuery("api_key", "key"), want: "||api_key=key&page=1"},
+	}
+	for i, c2 := range cases {
+		res, err := GetWith[string](c, "pets.io/pets?page=1", Config{Auth: c2.auth})
+		assert(t, err, nil)
+		if res != c2.want {
+			t.Errorf("case %d: expected %q, got %q", i, c2.want, res)
+		}
+	}
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var issued atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "pets" || secret != "secret" || r.PostFormValue("grant_type") != "client_credentials" || r.PostFormValue("scope") != "read write" {
+			w.WriteHeader(401)
+			return
+		}
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	m := &Mock{}
+	m.On("POST", "pets.io/pets").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		// the first token is revoked.
+		if req.Header.Get("Authorization") == "Bearer token1" && body == `{"name":"Charles"}` {
+			return mockResponse(req, 401, nil, nil), nil
+		}
+		return mockResponse(req, 200, nil, []byte(req.Header.Get("Authorization")+" "+body)), nil
+	})
+	oauth := &OAuth2ClientCredentials{TokenURL: tokenServer.URL, ClientID: "pets", ClientSecret: "secret", Scopes: []string{"read", "write"}}
+	c := &Client{HttpClient: m.HttpClient(), Auth: oauth.Intercept}
+
+	res, err := PostWith[string](c, "pets.io/pets", Pet{Name: "Lola"})
+	assert(t, err, nil)
+	assert(t, res, `Bearer token1 {"name":"Lola"}`)
+	// cached
+	res, err = PostWith[string](c, "pets.io/pets", Pet{Name: "Max"})
+	assert(t, err, nil)
+	assert(t, res, `Bearer token1 {"name":"Max"}`)
+	// renewed on 401 and the body is sent again
+	res, err = PostWith[string](c, "pets.io/pets", Pet{Name: "Charles"})
+	assert(t, err, nil)
+	assert(t, res, `Bearer token2 {"name":"Charles"}`)
+	assert(t, issued.Load(), int32(2))
+
+	bad := &OAuth2ClientCredentials{TokenURL: tokenServer.URL, ClientID: "pets", ClientSecret: "wrong"}
+	_, err = PostWith[string](&Client{HttpClient: m.HttpClient()}, "pets.io/pets", Pet{Name: "Lola"}, Config{Auth: bad.Intercept})
+	assertNotNil(t, err)
+}
+
+func TestOAuth2ClientCredentials_Expiry(t *testing.T) {
+	var issued atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := issued.Add(1)
+		fmt.Fprintf(w, `{"access_token":"token%d","expires_in":5}`, n)
+	}))
+	defer tokenServer.Close()
+	m := &Mock{}
+	m.On("GET", "pets.io/pets").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(req, 200, nil, []byte(req.Header.Get("Authorization"))), nil
+	})
+	// the token expires in 5 seconds which is within the expiry delta.
+	oauth := &OAuth2ClientCredentials{TokenURL: tokenServer.URL}
+	c := &Client{HttpClient: m.HttpClient(), Auth: oauth.Intercept}
+	for i := 1; i <= 2; i++ {
+		res, err := GetWith[string](c, "pets.io/pets")
+		assert(t, err, nil)
+		assert(t, res, fmt.Sprintf("Bearer token%d", i))
+	}
+}
+
+func TestOAuth2ClientCredentials_Concurrent401(t *testing.T) {
+	var issued atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := issued.Add(1)
+		fmt.Fprintf(w, `{"access_token":"token%d","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	const requests = 10
+	var rejected sync.WaitGroup
+	rejected.Add(requests)
+	m := &Mock{}
+	m.On("GET", "pets.io/pets").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		// the first token is revoked, all the requests are rejected together.
+		if req.Header.Get("Authorization") == "Bearer token1" {
+			rejected.Done()
+			rejected.Wait()
+			return mockResponse(req, 401, nil, nil), nil
+		}
+		return mockResponse(req, 200, nil, []byte(req.Header.Get("Authorization"))), nil
+	})
+	oauth := &OAuth2ClientCredentials{TokenURL: tokenServer.URL}
+	c := &Client{HttpClient: m.HttpClient(), Auth: oauth.Intercept}
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := GetWith[string](c, "pets.io/pets")
+			assert(t, err, nil)
+			assert(t, res, "Bearer token2")
+		}()
+	}
+	wg.Wait()
+	// token1 and the single renewed token2.
+	assert(t, issued.Load(), int32(2))
+}
@@ -0,0 +1,421 @@
+package fetch
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Cache is a private HTTP cache following RFC 9111. It stores the responses of GET and HEAD requests
+honoring Cache-Control (max-age, no-store, no-cache, stale-while-revalidate), Expires and Vary,
+and revalidates the stale responses with ETag and Last-Modified.
+Use its Intercept method as an interceptor.
+e.g.
+
+	cache := &fetch.Cache{}
+	petstore := &fetch.Client{Interceptors: []fetch.Interceptor{cache.Intercept}}
+	res, err := fetch.GetWith[fetch.Response[Pet]](petstore, "https://petstore.swagger.io/v2/pet/1")
+	fmt.Println("from cache:", res.Cached)
+
+Requests with Cache-Control: no-store bypass the cache, with no-cache they are always revalidated.
+Successful requests with other methods invalidate the cached responses of their URL.
+The responses with Vary are kept separately for every combination of the listed request headers.
+*/
+type Cache struct {
+	// Store keeps the responses. Defaults to NewMemoryStore(1000).
+	Store Store
+	// RevalidateTimeout limits the background revalidation of stale-while-revalidate. Defaults to 30 seconds.
+	RevalidateTimeout time.Duration
+
+	mu           sync.Mutex
+	store        Store
+	revalidating map[string]bool
+}
+
+// CachedResponse is a response kept in Store.
+type CachedResponse struct {
+	// Status is zero for the entry of the URL listing the Vary header names of its responses.
+	Status int
+	Header http.Header
+	Body   []byte
+	// Values of the request headers listed in the Vary header of the response.
+	VaryHeader http.Header
+	// Time when the response was received.
+	Time time.Time
+}
+
+// Store keeps the cached responses by keys made of the request method and URL.
+// The responses with Vary are kept by the keys with the values of the listed request headers appended,
+// while the key of the URL keeps the entry with zero Status listing their names.
+// It must be safe for concurrent use.
+type Store interface {
+	Get(key string) (CachedResponse, bool)
+	Set(key string, res CachedResponse)
+	Delete(key string)
+}
+
+var cacheableStatuses = []int{200, 203, 204, 300, 301, 308, 404, 405, 410, 414, 501}
+
+func (c *Cache) Intercept(req *http.Request, next Next) (*http.Response, error) {
+	store := c.getStore()
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		res, err := next(req)
+		if err == nil && req.Method != http.MethodOptions && req.Method != http.MethodTrace && res.StatusCode < 400 {
+			store.Delete(http.MethodGet + " " + req.URL.String())
+			store.Delete(http.MethodHead + " " + req.URL.String())
+		}
+		return res, err
+	}
+	reqCC := parseCacheControl(req.Header)
+	_, noStore := reqCC["no-store"]
+	// the conditional requests of the caller are left to the server.
+	if noStore || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return next(req)
+	}
+
+	key := req.Method + " " + req.URL.String()
+	cached, cachedKey, ok := lookup(store, key, req)
+	if !ok {
+		res, err := next(req)
+		if err != nil {
+			return nil, err
+		}
+		return c.save(store, key, req, res)
+	}
+
+	resCC := parseCacheControl(cached.Header)
+	_, reqNoCache := reqCC["no-cache"]
+	_, resNoCache := resCC["no-cache"]
+	age, lifetime := cached.age(), cached.lifetime()
+	if !reqNoCache && !resNoCache {
+		if age < lifetime {
+			return cached.response(req), nil
+		}
+		if swr, ok := cacheSeconds(resCC, "stale-while-revalidate"); ok && age < lifetime+swr {
+			c.revalidateInBackground(store, key, cachedKey, req, next, cached)
+			return cached.response(req), nil
+		}
+	}
+	return c.revalidate(store, key, req, next, cached)
+}
+
+// lookup returns the cached response matching the request and the key it's stored by.
+func lookup(store Store, key string, req *http.Request) (CachedResponse, string, bool) {
+	cached, ok := store.Get(key)
+	if !ok || !cached.isVaryIndex() {
+		return cached, key, ok && cached.varyMatches(req)
+	}
+	vkey := variantKey(key, varyNames(cached.Header), req)
+	variant, ok := store.Get(vkey)
+	// the variants stored before the URL was invalidated are outdated.
+	return variant, vkey, ok && !variant.Time.Before(cached.Time) && variant.varyMatches(req)
+}
+
+// variantKey is the key of the response with Vary, made of the values of the listed request headers.
+func variantKey(key string, names []string, req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range names {
+		b.WriteString("\n" + strings.ToLower(name) + ": " + strings.Join(req.Header.Values(name), ", "))
+	}
+	return b.String()
+}
+
+func (c *Cache) getStore() Store {
+	if c.Store != nil {
+		return c.Store
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.store == nil {
+		c.store = NewMemoryStore(1000)
+	}
+	return c.store
+}
+
+// revalidate makes the conditional request and serves the cached response if it's not modified.
+func (c *Cache) revalidate(store Store, key string, req *http.Request, next Next, cached CachedResponse) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if etag := cached.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lm := cached.Header.Get("Last-Modified"); lm != "" {
+		req.Header.Set("If-Modified-Since", lm)
+	}
+	res, err := next(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusNotModified {
+		return c.save(store, key, req, res)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	cached = cached.update(res.Header)
+	if names := varyNames(cached.Header); len(names) > 0 {
+		key = variantKey(key, names, req)
+	}
+	store.Set(key, cached)
+	return cached.response(req), nil
+}
+
+// revalidateInBackground revalidates the stale response after it's served,
+// once at a time for the key it's stored by.
+func (c *Cache) revalidateInBackground(store Store, key, cachedKey string, req *http.Request, next Next, cached CachedResponse) {
+	c.mu.Lock()
+	if c.revalidating[cachedKey] {
+		c.mu.Unlock()
+		return
+	}
+	if c.revalidating == nil {
+		c.revalidating = map[string]bool{}
+	}
+	c.revalidating[cachedKey] = true
+	c.mu.Unlock()
+
+	timeout := c.RevalidateTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	// the request context ends with the caller's request and its exchange isn't served by this revalidation.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), timeout)
+	req = req.WithContext(context.WithValue(ctx, exchangeKey{}, (*exchange)(nil)))
+	go func() {
+		defer func() {
+			cancel()
+			c.mu.Lock()
+			delete(c.revalidating, cachedKey)
+			c.mu.Unlock()
+		}()
+		res, err := c.revalidate(store, key, req, next, cached)
+		if err == nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+	}()
+}
+
+// save stores the response if it's cacheable. The returned response has the body read into memory.
+func (c *Cache) save(store Store, key string, req *http.Request, res *http.Response) (*http.Response, error) {
+	if !isCacheable(res) {
+		return res, nil
+	}
+	body, err := exchangeOf(req).readBody(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	varyHeader := http.Header{}
+	for _, name := range varyNames(res.Header) {
+		if vals := req.Header.Values(name); len(vals) > 0 {
+			varyHeader[http.CanonicalHeaderKey(name)] = vals
+		}
+	}
+	if req.Method == http.MethodHead {
+		body = nil
+	}
+	if names := varyNames(res.Header); len(names) > 0 {
+		index, ok := store.Get(key)
+		if !ok || !index.isVaryIndex() || !slices.Equal(varyNames(index.Header), names) {
+			store.Set(key, CachedResponse{Header: http.Header{"Vary": {strings.Join(names, ", ")}}, Time: time.Now()})
+		}
+		key = variantKey(key, names, req)
+	}
+	store.Set(key, CachedResponse{
+		Status:     res.StatusCode,
+		Header:     res.Header.Clone(),
+		Body:       body,
+		VaryHeader: varyHeader,
+		Time:       time.Now(),
+	})
+	return res, nil
+}
+
+func isCacheable(res *http.Response) bool {
+	if !slices.Contains(cacheableStatuses, res.StatusCode) {
+		return false
+	}
+	cc := parseCacheControl(res.Header)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if slices.Contains(varyNames(res.Header), "*") {
+		return false
+	}
+	_, hasMaxAge := cacheSeconds(cc, "max-age")
+	_, noCache := cc["no-cache"]
+	return hasMaxAge || noCache || res.Header.Get("Expires") != "" ||
+		res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != ""
+}
+
+// response makes the HTTP response of the cached one, marking the exchange as cached.
+func (r CachedResponse) response(req *http.Request) *http.Response {
+	if ex := exchangeOf(req); ex != nil {
+		ex.cached = true
+	}
+	header := r.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(r.age()/time.Second)))
+	return mockResponse(req, r.Status, header, r.Body)
+}
+
+// age is the time since the response was generated by the server.
+func (r CachedResponse) age() time.Duration {
+	age := time.Since(r.Time)
+	if sec, err := strconv.Atoi(r.Header.Get("Age")); err == nil && sec > 0 {
+		age += time.Duration(sec) * time.Second
+	}
+	return age
+}
+
+// lifetime is how long the response stays fresh.
+func (r CachedResponse) lifetime() time.Duration {
+	if maxAge, ok := cacheSeconds(parseCacheControl(r.Header), "max-age"); ok {
+		return maxAge
+	}
+	expires, err := http.ParseTime(r.Header.Get("Expires"))
+	if err != nil {
+		return 0
+	}
+	date, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil {
+		date = r.Time
+	}
+	return expires.Sub(date)
+}
+
+func (r CachedResponse) isVaryIndex() bool {
+	return r.Status == 0
+}
+
+func (r CachedResponse) varyMatches(req *http.Request) bool {
+	for _, name := range varyNames(r.Header) {
+		if !slices.Equal(r.VaryHeader.Values(name), req.Header.Values(name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// update returns the copy of the response with the headers of the 304 response.
+func (r CachedResponse) update(header http.Header) CachedResponse {
+	r.Header = r.Header.Clone()
+	r.Header.Del("Age")
+	for k, v := range header {
+		if k == "Content-Length" {
+			continue
+		}
+		r.Header[k] = v
+	}
+	r.Time = time.Now()
+	return r
+}
+
+func varyNames(h http.Header) []string {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// parseCacheControl returns the directives of Cache-Control header with lowercased names.
+func parseCacheControl(h http.Header) map[string]string {
+	cc := map[string]string{}
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if name != "" {
+				cc[strings.ToLower(name)] = strings.Trim(value, `"`)
+			}
+		}
+	}
+	return cc
+}
+
+func cacheSeconds(cc map[string]string, name string) (time.Duration, bool) {
+	v, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	sec, err := strconv.Atoi(v)
+	if err != nil || sec < 0 {
+		return 0, false
+	}
+	return time.Duration(sec) * time.Second, true
+}
+
+// MemoryStore is Store keeping the responses in memory,
+// evicting the least recently used ones above the limit.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List
+	entries    map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key string
+	res CachedResponse
+}
+
+// NewMemoryStore creates MemoryStore keeping at most maxEntries responses.
+// Zero or negative means no limit.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{maxEntries: maxEntries, lru: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (s *MemoryStore) Get(key string) (CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return CachedResponse{}, false
+	}
+	s.lru.MoveToFront(e)
+	return e.Value.(*memoryEntry).res, true
+}
+
+func (s *MemoryStore) Set(key string, res CachedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		e.Value.(*memoryEntry).res = res
+		s.lru.MoveToFront(e)
+		return
+	}
+	s.entries[key] = s.lru.PushFront(&memoryEntry{key: key, res: res})
+	if s.maxEntries > 0 && s.lru.Len() > s.maxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.lru.Remove(e)
+		delete(s.entries, key)
+	}
+}
+
+// Len returns the number of the stored responses.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
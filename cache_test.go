@@ -0,0 +1,200 @@
+package fetch
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCacheMock(header http.Header) (*Mock, *atomic.Int32) {
+	var calls atomic.Int32
+	m := &Mock{}
+	m.On("*", "pets.io/pets/1").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		n := calls.Add(1)
+		if req.Method != http.MethodGet {
+			return mockResponse(req, 204, nil, nil), nil
+		}
+		if etag := header.Get("ETag"); etag != "" && req.Header.Get("If-None-Match") == etag {
+			return mockResponse(req, 304, http.Header{"Etag": {etag}}, nil), nil
+		}
+		h := header.Clone()
+		h.Set("Content-Type", "application/json")
+		return mockResponse(req, 200, h, []byte(`{"name":"Lola`+strconv.Itoa(int(n))+`"}`)), nil
+	})
+	return m, &calls
+}
+
+func TestCache_Fresh(t *testing.T) {
+	m, calls := newCacheMock(http.Header{"Cache-Control": {"max-age=60"}})
+	cache := &Cache{}
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{cache.Intercept}}
+
+	res, err := GetWith[Response[Pet]](c, "pets.io/pets/1")
+	assert(t, err, nil)
+	assert(t, res.Cached, false)
+	assert(t, res.Body.Name, "Lola1")
+
+	res, err = GetWith[Response[Pet]](c, "pets.io/pets/1")
+	assert(t, err, nil)
+	assert(t, res.Cached, true)
+	assert(t, res.Body.Name, "Lola1")
+	assert(t, res.Headers["Age"], "0")
+	assert(t, calls.Load(), int32(1))
+
+	// no-store bypasses the cache
+	res, err = GetWith[Response[Pet]](c, "pets.io/pets/1", Config{Headers: map[string]string{"Cache-Control": "no-store"}})
+	assert(t, err, nil)
+	assert(t, res.Cached, false)
+	assert(t, res.Body.Name, "Lola2")
+
+	// unsafe methods invalidate
+	_, err = DeleteWith[Empty](c, "pets.io/pets/1")
+	assert(t, err, nil)
+	res, err = GetWith[Response[Pet]](c, "pets.io/pets/1")
+	assert(t, err, nil)
+	assert(t, res.Cached, false)
+	assert(t, res.Body.Name, "Lola4")
+}
+
+func TestCache_Revalidate(t *testing.T) {
+	m, calls := newCacheMock(http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}})
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{(&Cache{}).Intercept}}
+
+	res, err := GetWith[Response[Pet]](c, "pets.io/pets/1")
+	assert(t, err, nil)
+	assert(t, res.Cached, false)
+
+	res, err = GetWith[Response[Pet]](c, "pets.io/pets/1")
+	assert(t, err, nil)
+	assert(t, res.Cached, true)
+	assert(t, res.Status, 200)
+	assert(t, res.Body.Name, "Lola1")
+	assert(t, calls.Load(), int32(2))
+}
+
+func TestCache_Expires(t *testing.T) {
+	m, calls := newCacheMock(http.Header{"Expires": {time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}})
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{(&Cache{}).Intercept}}
+	for i := 1; i <= 2; i++ {
+		res, err := GetWith[Response[Pet]](c, "pets.io/pets/1")
+		assert(t, err, nil)
+		assert(t, res.Cached, false)
+		assert(t, res.Body.Name, "Lola"+strconv.Itoa(i))
+	}
+	assert(t, calls.Load(), int32(2))
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	m, calls := newCacheMock(http.Header{"Cache-Control": {"max-age=0, stale-while-revalidate=60"}})
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{(&Cache{}).Intercept}}
+
+	_, err := GetWith[Pet](c, "pets.io/pets/1")
+	assert(t, err, nil)
+	res, err := GetWith[Response[Pet]](c, "pets.io/pets/1")
+	assert(t, err, nil)
+	assert(t, res.Cached, true)
+	assert(t, res.Body.Name, "Lola1")
+
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert(t, calls.Load(), int32(2))
+	// the background revalidation updated the cache
+	deadline = time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if pet, err := GetWith[Pet](c, "pets.io/pets/1"); err == nil && pet.Name != "Lola1" {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("cache wasn't revalidated")
+}
+
+func TestCache_Vary(t *testing.T) {
+	m, calls := newCacheMock(http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept-Language"}})
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{(&Cache{}).Intercept}}
+	en := Config{Headers: map[string]string{"Accept-Language": "en"}}
+	fr := Config{Headers: map[string]string{"Accept-Language": "fr"}}
+
+	res, err := GetWith[Response[Pet]](c, "pets.io/pets/1", en)
+	assert(t, err, nil)
+	res, err = GetWith[Response[Pet]](c, "pets.io/pets/1", en)
+	assert(t, err, nil)
+	assert(t, res.Cached, true)
+	res, err = GetWith[Response[Pet]](c, "pets.io/pets/1", fr)
+	assert(t, err, nil)
+	assert(t, res.Cached, false)
+	assert(t, calls.Load(), int32(2))
+
+	// every variant is kept.
+	for _, cfg := range []Config{en, fr} {
+		res, err = GetWith[Response[Pet]](c, "pets.io/pets/1", cfg)
+		assert(t, err, nil)
+		assert(t, res.Cached, true)
+	}
+	assert(t, calls.Load(), int32(2))
+
+	// invalidating the URL invalidates all the variants.
+	_, err = DeleteWith[Empty](c, "pets.io/pets/1")
+	assert(t, err, nil)
+	for _, cfg := range []Config{en, en, fr} {
+		_, err = GetWith[Response[Pet]](c, "pets.io/pets/1", cfg)
+		assert(t, err, nil)
+	}
+	assert(t, calls.Load(), int32(5))
+}
+
+func TestCache_RevalidateTimeout(t *testing.T) {
+	var calls atomic.Int32
+	m := &Mock{}
+	m.On("GET", "pets.io/pets/1").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 2 {
+			// the first revalidation hangs.
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return mockResponse(req, 200, http.Header{"Cache-Control": {"max-age=0, stale-while-revalidate=60"}}, []byte(`{"name":"Lola"}`)), nil
+	})
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{(&Cache{RevalidateTimeout: 10 * time.Millisecond}).Intercept}}
+
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 3 && time.Now().Before(deadline) {
+		res, err := GetWith[Response[Pet]](c, "pets.io/pets/1")
+		assert(t, err, nil)
+		assert(t, res.Body.Name, "Lola")
+		time.Sleep(time.Millisecond)
+	}
+	if calls.Load() < 3 {
+		t.Errorf("the hanging revalidation blocked the next ones")
+	}
+}
+
+func TestCache_NotCacheable(t *testing.T) {
+	m, calls := newCacheMock(http.Header{"Cache-Control": {"no-store, max-age=60"}})
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{(&Cache{}).Intercept}}
+	for i := 0; i < 2; i++ {
+		res, err := GetWith[Response[Pet]](c, "pets.io/pets/1")
+		assert(t, err, nil)
+		assert(t, res.Cached, false)
+	}
+	assert(t, calls.Load(), int32(2))
+}
+
+func TestMemoryStore_LRU(t *testing.T) {
+	s := NewMemoryStore(2)
+	s.Set("a", CachedResponse{Status: 1})
+	s.Set("b", CachedResponse{Status: 2})
+	s.Get("a")
+	s.Set("c", CachedResponse{Status: 3})
+	_, ok := s.Get("b")
+	assert(t, ok, false)
+	a, ok := s.Get("a")
+	assert(t, ok, true)
+	assert(t, a.Status, 1)
+	assert(t, s.Len(), 2)
+	s.Delete("a")
+	assert(t, s.Len(), 1)
+}
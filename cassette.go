@@ -0,0 +1,268 @@
+package fetch
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/glossd/fetch/internal/json"
+)
+
+type CassetteMode int
+
+const (
+	// CassetteRecord sends the requests and records the exchanges.
+	CassetteRecord CassetteMode = iota
+	// CassetteReplay serves the responses from the cassette file without sending the requests.
+	CassetteReplay
+)
+
+const redacted = "REDACTED"
+
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+/*
+Cassette records HTTP exchanges into a JSON file and replays them offline.
+Use its Intercept method as an interceptor.
+e.g.
+
+	cassette := &fetch.Cassette{Path: "testdata/petstore.json", Mode: fetch.CassetteRecord}
+	petstore := &fetch.Client{Interceptors: []fetch.Interceptor{cassette.Intercept}}
+	pet, err := fetch.GetWith[Pet](petstore, "https://petstore.swagger.io/v2/pet/1")
+	...
+	err = cassette.Save()
+
+Switch Mode to CassetteReplay to serve the recorded responses.
+*/
+type Cassette struct {
+	// Path of the cassette file.
+	Path string
+	Mode CassetteMode
+	// Match decides if the recorded exchange can be replayed for the request.
+	// Defaults to comparing methods and URLs.
+	Match func(req *http.Request, body string, recorded Exchange) bool
+	// Headers whose values are replaced with REDACTED before saving.
+	// Defaults to Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	Redact []string
+	// Filter is called with every exchange before saving, e.g. to hide secrets in the body.
+	Filter func(e *Exchange)
+
+	mu        sync.Mutex
+	exchanges []Exchange
+	played    []bool
+	loaded    bool
+}
+
+// Exchange is a recorded request and its response.
+type Exchange struct {
+	Request  RecordedRequest
+	Response RecordedResponse
+}
+
+type RecordedRequest struct {
+	Method  string
+	URL     string
+	Headers map[string][]string
+	Body    string
+	// BodyEncoding is base64 in the cassette file if Body isn't valid UTF-8, e.g. gzip or images.
+	// The body is decoded when the cassette is loaded.
+	BodyEncoding string `json:",omitempty"`
+}
+
+type RecordedResponse struct {
+	Status  int
+	Headers map[string][]string
+	Body    string
+	// BodyEncoding is base64 in the cassette file if Body isn't valid UTF-8, e.g. gzip or images.
+	// The body is decoded when the cassette is loaded.
+	BodyEncoding string `json:",omitempty"`
+}
+
+const base64Encoding = "base64"
+
+// encodeBody encodes the body in base64 if it can't be saved as JSON string without changes.
+func encodeBody(body string) (string, string) {
+	if utf8.ValidString(body) {
+		return body, ""
+	}
+	return base64.StdEncoding.EncodeToString([]byte(body)), base64Encoding
+}
+
+func decodeBody(body, encoding string) (string, error) {
+	switch encoding {
+	case "":
+		return body, nil
+	case base64Encoding:
+		b, err := base64.StdEncoding.DecodeString(body)
+		return string(b), err
+	default:
+		return "", fmt.Errorf("unknown body encoding %s", encoding)
+	}
+}
+
+// Intercept records or replays the request depending on the Mode.
+func (c *Cassette) Intercept(req *http.Request, next Next) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.Mode == CassetteReplay {
+		return c.replay(req, reqBody)
+	}
+
+	res, err := next(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exchanges = append(c.exchanges, Exchange{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: req.Header.Clone(),
+			Body:    string(reqBody),
+		},
+		Response: RecordedResponse{
+			Status:  res.StatusCode,
+			Headers: res.Header.Clone(),
+			Body:    string(resBody),
+		},
+	})
+	return res, nil
+}
+
+func (c *Cassette) replay(req *http.Request, body string) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loaded {
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+	}
+	match := c.Match
+	if match == nil {
+		match = func(req *http.Request, _ string, e Exchange) bool {
+			return req.Method == e.Request.Method && req.URL.String() == e.Request.URL
+		}
+	}
+	// the same request can be recorded several times with different responses,
+	// the exchanges are played in order and the last one is repeated.
+	found := -1
+	for i, e := range c.exchanges {
+		if match(req, body, e) {
+			found = i
+			if !c.played[i] {
+				break
+			}
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("cassette %s has no exchange for %s %s", c.Path, req.Method, req.URL)
+	}
+	c.played[found] = true
+	e := c.exchanges[found]
+	return mockResponse(req, e.Response.Status, http.Header(e.Response.Headers).Clone(), []byte(e.Response.Body)), nil
+}
+
+func (c *Cassette) load() error {
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return fmt.Errorf("read cassette: %w", err)
+	}
+	exchanges, err := Unmarshal[[]Exchange](string(data))
+	if err != nil {
+		return fmt.Errorf("parse cassette %s: %w", c.Path, err)
+	}
+	for i := range exchanges {
+		e := &exchanges[i]
+		e.Request.Body, err = decodeBody(e.Request.Body, e.Request.BodyEncoding)
+		if err == nil {
+			e.Response.Body, err = decodeBody(e.Response.Body, e.Response.BodyEncoding)
+		}
+		if err != nil {
+			return fmt.Errorf("parse cassette %s: %w", c.Path, err)
+		}
+		e.Request.BodyEncoding, e.Response.BodyEncoding = "", ""
+	}
+	c.exchanges = exchanges
+	c.played = make([]bool, len(exchanges))
+	c.loaded = true
+	return nil
+}
+
+// Save writes the recorded exchanges into the cassette file, redacting the secrets.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	redact := c.Redact
+	if redact == nil {
+		redact = defaultRedactedHeaders
+	}
+	exchanges := make([]Exchange, len(c.exchanges))
+	for i, e := range c.exchanges {
+		e.Request.Headers = redactHeaders(e.Request.Headers, redact)
+		e.Response.Headers = redactHeaders(e.Response.Headers, redact)
+		if c.Filter != nil {
+			c.Filter(&e)
+		}
+		e.Request.Body, e.Request.BodyEncoding = encodeBody(e.Request.Body)
+		e.Response.Body, e.Response.BodyEncoding = encodeBody(e.Response.Body)
+		exchanges[i] = e
+	}
+	s, err := Marshal(exchanges)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	err = json.Indent(&buf, []byte(s), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.Path, buf.Bytes(), 0644)
+}
+
+func redactHeaders(h http.Header, names []string) http.Header {
+	h = h.Clone()
+	for _, name := range names {
+		if vals := h.Values(name); len(vals) > 0 {
+			h.Set(name, redacted)
+		}
+	}
+	return h
+}
+
+// readRequestBody reads the body leaving it readable for the next interceptors.
+func readRequestBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		b, err := io.ReadAll(body)
+		return string(b), err
+	}
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return string(b), nil
+}
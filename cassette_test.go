@@ -0,0 +1,106 @@
+package fetch
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pets.json")
+	m := &Mock{}
+	m.On("GET", "/pets/1").ReplyHeader("Set-Cookie", "session=1").Reply(200, Pet{Name: "Lola"})
+	m.On("POST", "/pets").Reply(201, `{"id":2}`)
+
+	recorder := &Cassette{Path: path}
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{recorder.Intercept}}
+	pet, err := GetWith[Pet](c, "pets.io/pets/1", Config{Headers: map[string]string{"Authorization": "secret"}})
+	if err != nil || pet.Name != "Lola" {
+		t.Fatalf("record: pet=%v, err=%v", pet, err)
+	}
+	_, err = PostWith[M](c, "pets.io/pets", Pet{Name: "Charles"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "session=1") {
+		t.Errorf("secrets should be redacted: %s", data)
+	}
+
+	player := &Cassette{Path: path, Mode: CassetteReplay}
+	offline := &Client{HttpClient: (&Mock{FailUnmatched: true}).HttpClient(), Interceptors: []Interceptor{player.Intercept}}
+	res, err := GetWith[Response[Pet]](offline, "pets.io/pets/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != 200 || res.Body.Name != "Lola" || res.Headers["Content-Type"] != "application/json" {
+		t.Errorf("wrong replayed response: %+v", res)
+	}
+	id, err := PostWith[M](offline, "pets.io/pets", Pet{Name: "Charles"})
+	if err != nil || id["id"] != 2.0 {
+		t.Errorf("wrong replayed response: %v, err=%v", id, err)
+	}
+	_, err = GetWith[Pet](offline, "pets.io/pets/2")
+	if err == nil {
+		t.Errorf("expected error for unrecorded request")
+	}
+}
+
+func TestCassette_Match(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "echo.json")
+	recorder := &Cassette{Path: path}
+	c := &Client{HttpClient: testMock.HttpClient(), Interceptors: []Interceptor{recorder.Intercept}}
+	for _, body := range []string{"first", "second"} {
+		if _, err := PostWith[string](c, "echo.me", body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	player := &Cassette{Path: path, Mode: CassetteReplay, Match: func(_ *http.Request, body string, e Exchange) bool {
+		return body == e.Request.Body
+	}}
+	offline := &Client{Interceptors: []Interceptor{player.Intercept}}
+	for _, body := range []string{"second", "first", "second"} {
+		res, err := PostWith[string](offline, "echo.me", body)
+		if err != nil || res != body {
+			t.Errorf("wrong replay: %s, err=%v", res, err)
+		}
+	}
+}
+
+func TestCassette_BinaryBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "binary.json")
+	binary := string([]byte{0x1f, 0x8b, 0xff, 0x00, 0x80, 0x61})
+	m := &Mock{}
+	m.On("PUT", "/files/1").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(req, 200, http.Header{"Content-Type": {"application/octet-stream"}}, []byte(binary)), nil
+	})
+
+	recorder := &Cassette{Path: path}
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{recorder.Intercept}}
+	_, err := PutWith[string](c, "files.io/files/1", []byte(binary))
+	assert(t, err, nil)
+	assert(t, recorder.Save(), nil)
+
+	var body string
+	player := &Cassette{Path: path, Mode: CassetteReplay, Match: func(req *http.Request, b string, e Exchange) bool {
+		body = e.Request.Body
+		return b == e.Request.Body
+	}}
+	offline := &Client{HttpClient: (&Mock{FailUnmatched: true}).HttpClient(), Interceptors: []Interceptor{player.Intercept}}
+	res, err := PutWith[string](offline, "files.io/files/1", []byte(binary))
+	assert(t, err, nil)
+	assert(t, res, binary)
+	assert(t, body, binary)
+}
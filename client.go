@@ -0,0 +1,118 @@
+package fetch
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+var defaultClient = &Client{HttpClient: &http.Client{}}
+
+/*
+Client holds the settings shared by the requests made through it.
+Use it with the generic functions ending with `With`, e.g.
+
+	petstore := &fetch.Client{BaseURL: "https://petstore.swagger.io/v2"}
+	pet, err := fetch.GetWith[Pet](petstore, "/pet/1")
+
+The package-level functions Get, Post, etc. use the default client,
+which is configured with SetBaseURL and SetHttpClient.
+The zero value is ready to use.
+*/
+type Client struct {
+	// BaseURL is prepended to the URLs without protocol.
+	BaseURL string
+	// Defaults to http.DefaultClient.
+	HttpClient *http.Client
+	// Jar stores the cookies of the responses and sends them with the next requests.
+	// Replaces the jar of HttpClient. Use net/http/cookiejar to create one.
+	Jar http.CookieJar
+	// Headers are sent with every request. Config.Headers take precedence over them.
+	Headers map[string]string
+	// Timeout is applied to every request unless Config has Ctx or Timeout specified.
+	Timeout time.Duration
+	// Retry is applied to every request unless Config has Retry specified.
+	Retry Retry
+	// Interceptors are called in order for every request.
+	Interceptors []Interceptor
+	// Auth authenticates every request, e.g. BearerAuth or OAuth2ClientCredentials.Intercept.
+	// It is called after the interceptors.
+	Auth Interceptor
+	// ErrorHook is called with the errors which can't be returned,
+	// e.g. failing to close the response body. Defaults to logging them with Logger or slog.Default().
+	ErrorHook func(err error)
+	// Logger records every attempt of the requests. See LogOptions for the levels.
+	Logger     *slog.Logger
+	LogOptions LogOptions
+	// Metrics observes every request, including its retries, until the response body is closed.
+	Metrics Metrics
+}
+
+func (c *Client) httpClient() *http.Client {
+	hc := c.HttpClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	if c.Jar != nil {
+		withJar := *hc
+		withJar.Jar = c.Jar
+		return &withJar
+	}
+	return hc
+}
+
+func (c *Client) errorHook(err error) {
+	if c.ErrorHook != nil {
+		c.ErrorHook(err)
+		return
+	}
+	logger := c.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Error(err.Error())
+}
+
+func GetWith[T any](c *Client, url string, config ...Config) (T, error) {
+	if len(config) == 0 {
+		config = []Config{{}}
+	}
+	config[0].Method = http.MethodGet
+	return DoWith[T](c, url, config...)
+}
+
+func PostWith[T any](c *Client, url string, body any, config ...Config) (T, error) {
+	return requestWithBody[T](c, url, http.MethodPost, body, config...)
+}
+
+func PutWith[T any](c *Client, url string, body any, config ...Config) (T, error) {
+	return requestWithBody[T](c, url, http.MethodPut, body, config...)
+}
+
+func PatchWith[T any](c *Client, url string, body any, config ...Config) (T, error) {
+	return requestWithBody[T](c, url, http.MethodPatch, body, config...)
+}
+
+func DeleteWith[T any](c *Client, url string, config ...Config) (T, error) {
+	if len(config) == 0 {
+		config = []Config{{}}
+	}
+	config[0].Method = http.MethodDelete
+	return DoWith[T](c, url, config...)
+}
+
+func HeadWith[T any](c *Client, url string, config ...Config) (T, error) {
+	if len(config) == 0 {
+		config = []Config{{}}
+	}
+	config[0].Method = http.MethodHead
+	return DoWith[T](c, url, config...)
+}
+
+func OptionsWith[T any](c *Client, url string, config ...Config) (T, error) {
+	if len(config) == 0 {
+		config = []Config{{}}
+	}
+	config[0].Method = http.MethodOptions
+	return DoWith[T](c, url, config...)
+}
@@ -0,0 +1,83 @@
+package fetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	withoutMock(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/pets", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"` + r.Header.Get("X-Pet") + `","method":"` + r.Method + `"}`))
+	})
+	mux.HandleFunc("/v1/delay", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := &Client{
+		BaseURL: server.URL + "/v1",
+		Headers: map[string]string{"X-Pet": "Lola"},
+		Timeout: 10 * time.Millisecond,
+	}
+	res, err := GetWith[M](c, "/pets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res["name"] != "Lola" || res["method"] != "GET" {
+		t.Errorf("wrong response: %v", res)
+	}
+
+	res, err = PostWith[M](c, "/pets", nil, Config{Headers: map[string]string{"X-Pet": "Charles"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res["name"] != "Charles" || res["method"] != "POST" {
+		t.Errorf("config headers should override client headers: %v", res)
+	}
+
+	_, err = GetWith[Empty](c, "/delay")
+	if err == nil {
+		t.Errorf("expected client timeout")
+	}
+	_, err = GetWith[Empty](c, "/delay", Config{Timeout: time.Second})
+	if err != nil {
+		t.Errorf("config timeout should override client timeout, got %s", err)
+	}
+}
+
+func TestClient_Parallel(t *testing.T) {
+	withoutMock(t)
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+	}
+	first, second := newServer("first"), newServer("second")
+	defer first.Close()
+	defer second.Close()
+
+	clients := map[string]*Client{"first": {BaseURL: first.URL}, "second": {BaseURL: second.URL}}
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		for name, c := range clients {
+			go func(name string, c *Client) {
+				res, err := GetWith[string](c, "/")
+				if err == nil && res != name {
+					err = nonHttpErr("wrong client: ", &Error{Msg: res})
+				}
+				errs <- err
+			}(name, c)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package fetch
+
+import (
+	"encoding"
+	"encoding/xml"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+Codec marshals and unmarshals the bodies of a media type.
+The codec is selected by Content-Type of the response and the request of ToHandlerFunc,
+and by Accept header for the response of ToHandlerFunc.
+Built-in codecs are JSON, XML, form and plain text. JSON is the default one.
+*/
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var codecsMu sync.RWMutex
+var codecs = map[string]Codec{
+	"application/json": jsonCodec{},
+	"application/xml":  xmlCodec{},
+	"text/xml":         xmlCodec{},
+	formContentType:    formCodec{},
+	"text/plain":       textCodec{},
+}
+
+// RegisterCodec sets the codec of the media type e.g. application/yaml, replacing the existing one.
+func RegisterCodec(mediaType string, c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[strings.ToLower(mediaType)] = c
+}
+
+// codecFor finds the codec of the content type. The structured syntax suffixes +json and +xml
+// fall back to JSON and XML, e.g. application/problem+json.
+func codecFor(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	if c, ok := codecs[mediaType]; ok {
+		return c, true
+	}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		switch mediaType[i+1:] {
+		case "json":
+			return codecs["application/json"], true
+		case "xml":
+			return codecs["application/xml"], true
+		}
+	}
+	return nil, false
+}
+
+// negotiate selects the media type of the response by the Accept header. Defaults to JSON.
+// Only the registered media types are considered, without the suffix fallback of codecFor,
+// and the first wildcard selects JSON, e.g. for text/html,application/xhtml+xml,*/* of browsers.
+func negotiate(accept string) (string, Codec) {
+	type option struct {
+		mediaType string
+		q         float64
+	}
+	var options []option
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		if q > 0 {
+			options = append(options, option{mediaType: mediaType, q: q})
+		}
+	}
+	slices.SortStableFunc(options, func(a, b option) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	for _, o := range options {
+		if strings.Contains(o.mediaType, "*") {
+			break
+		}
+		if c, ok := codecs[o.mediaType]; ok {
+			return o.mediaType, c
+		}
+	}
+	return "application/json", jsonCodec{}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	s, err := Marshal(v)
+	return []byte(s), err
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return UnmarshalInto(string(data), v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
+
+type formCodec struct{}
+
+func (formCodec) Marshal(v any) ([]byte, error) {
+	s, err := MarshalForm(v)
+	return []byte(s), err
+}
+
+func (formCodec) Unmarshal(data []byte, v any) error {
+	return UnmarshalForm(string(data), v)
+}
+
+// textCodec uses encoding.TextMarshaler and encoding.TextUnmarshaler,
+// falling back to JSON, because a lot of servers send JSON as plain text.
+type textCodec struct{}
+
+func (textCodec) Marshal(v any) ([]byte, error) {
+	if tm, ok := v.(encoding.TextMarshaler); ok {
+		return tm.MarshalText()
+	}
+	return jsonCodec{}.Marshal(v)
+}
+
+func (textCodec) Unmarshal(data []byte, v any) error {
+	if tu, ok := v.(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText(data)
+	}
+	return jsonCodec{}.Unmarshal(data, v)
+}
@@ -0,0 +1,133 @@
+package fetch
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type xmlPet struct {
+	Name string `xml:"name"`
+}
+
+// csvCodec handles [][]string
+type csvCodec struct{}
+
+func (csvCodec) Marshal(v any) ([]byte, error) {
+	rows, ok := v.([][]string)
+	if !ok {
+		return nil, errors.New("csv requires [][]string")
+	}
+	var lines []string
+	for _, row := range rows {
+		lines = append(lines, strings.Join(row, ","))
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func (csvCodec) Unmarshal(data []byte, v any) error {
+	rows, ok := v.(*[][]string)
+	if !ok {
+		return errors.New("csv requires *[][]string")
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		*rows = append(*rows, strings.Split(line, ","))
+	}
+	return nil
+}
+
+func TestCodecs_Response(t *testing.T) {
+	RegisterCodec("text/csv", csvCodec{})
+	m := &Mock{}
+	m.On("GET", "/xml").ReplyHeader("Content-Type", "application/xml; charset=utf-8").Reply(200, "<pet><name>Lola</name></pet>")
+	m.On("GET", "/problem").ReplyHeader("Content-Type", "application/problem+json").Reply(200, `{"name":"Charles"}`)
+	m.On("GET", "/csv").ReplyHeader("Content-Type", "text/csv").Reply(200, "a,b\nc,d")
+	m.On("GET", "/text").ReplyHeader("Content-Type", "text/plain").Reply(200, `{"name":"Max"}`)
+	c := &Client{HttpClient: m.HttpClient()}
+
+	p, err := GetWith[xmlPet](c, "pets.io/xml")
+	assert(t, err, nil)
+	assert(t, p.Name, "Lola")
+
+	res, err := GetWith[Response[Pet]](c, "pets.io/problem")
+	assert(t, err, nil)
+	assert(t, res.Body.Name, "Charles")
+
+	rows, err := GetWith[[][]string](c, "pets.io/csv")
+	assert(t, err, nil)
+	if len(rows) != 2 || rows[1][1] != "d" {
+		t.Errorf("wrong csv: %v", rows)
+	}
+
+	p2, err := GetWith[Pet](c, "pets.io/text")
+	assert(t, err, nil)
+	assert(t, p2.Name, "Max")
+
+	raw, err := GetWith[string](c, "pets.io/xml")
+	assert(t, err, nil)
+	assert(t, raw, "<pet><name>Lola</name></pet>")
+}
+
+func TestCodecs_RequestBody(t *testing.T) {
+	m := &Mock{}
+	m.On("POST", "/pets").MatchBody("<xmlPet><name>Lola</name></xmlPet>").Reply(201, "created")
+	c := &Client{HttpClient: m.HttpClient()}
+	res, err := PostWith[string](c, "pets.io/pets", xmlPet{Name: "Lola"}, Config{Headers: map[string]string{"Content-Type": "application/xml"}})
+	assert(t, err, nil)
+	assert(t, res, "created")
+}
+
+func TestToHandlerFunc_Accept(t *testing.T) {
+	f := ToHandlerFunc(func(in xmlPet) (xmlPet, error) {
+		return xmlPet{Name: in.Name + "!"}, nil
+	})
+	mw := newMockWriter()
+	r, err := http.NewRequest("POST", "/pets", bytes.NewBufferString("<pet><name>Lola</name></pet>"))
+	assert(t, err, nil)
+	r.Header.Set("Content-Type", "text/xml")
+	r.Header.Set("Accept", "application/json;q=0.5, application/xml")
+	f(mw, r)
+	assert(t, mw.status, 200)
+	assert(t, mw.Header().Get("Content-Type"), "application/xml")
+	assert(t, mw.body, "<xmlPet><name>Lola!</name></xmlPet>")
+
+	mw = newMockWriter()
+	r, err = http.NewRequest("POST", "/pets", bytes.NewBufferString(`{"name":"Lola"}`))
+	assert(t, err, nil)
+	r.Header.Set("Accept", "image/png, */*")
+	f(mw, r)
+	assert(t, mw.Header().Get("Content-Type"), "application/json")
+	assert(t, mw.body, `{"name":"Lola!"}`)
+}
+
+func TestToHandlerFunc_BrowserAccept(t *testing.T) {
+	browser := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	f := ToHandlerFuncEmptyIn(func() (M, error) {
+		return M{"name": "Lola"}, nil
+	})
+	mw := newMockWriter()
+	r, err := http.NewRequest("GET", "/pets/1", nil)
+	assert(t, err, nil)
+	r.Header.Set("Accept", browser)
+	f(mw, r)
+	assert(t, mw.status, 200)
+	assert(t, mw.Header().Get("Content-Type"), "application/json")
+	assert(t, mw.body, `{"name":"Lola"}`)
+
+	// a struct is marshalled into XML, which the browser accepts with q=0.9.
+	fp := ToHandlerFuncEmptyIn(func() (xmlPet, error) {
+		return xmlPet{Name: "Lola"}, nil
+	})
+	mw = newMockWriter()
+	fp(mw, r)
+	assert(t, mw.Header().Get("Content-Type"), "application/xml")
+	assert(t, mw.body, "<xmlPet><name>Lola</name></xmlPet>")
+
+	mw = newMockWriter()
+	r.Header.Set("Accept", "text/plain")
+	fp(mw, r)
+	assert(t, mw.Header().Get("Content-Type"), "application/json")
+	assert(t, mw.body, `{"name":"Lola"}`)
+}
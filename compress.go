@@ -0,0 +1,197 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultCompressMinSize = 1024
+
+// Compression configures encoding of the request body.
+type Compression struct {
+	// Encoding is "gzip" or "deflate". Empty means no compression.
+	Encoding string
+	// Bodies smaller than MinSize bytes are sent as is, the streamed bodies of unknown length are always compressed.
+	// Defaults to 1024.
+	MinSize int
+}
+
+func (c Compression) minSize() int {
+	if c.MinSize == 0 {
+		return defaultCompressMinSize
+	}
+	return c.MinSize
+}
+
+func (c Compression) writer(w io.Writer) (io.WriteCloser, error) {
+	switch c.Encoding {
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "deflate":
+		return zlib.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", c.Encoding)
+	}
+}
+
+// compress encodes the body if it isn't smaller than the minimal size.
+func (c Compression) compress(body string) (string, bool, error) {
+	if c.Encoding == "" || len(body) < c.minSize() {
+		return body, false, nil
+	}
+	var buf bytes.Buffer
+	w, err := c.writer(&buf)
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := io.WriteString(w, body); err != nil {
+		return "", false, err
+	}
+	if err := w.Close(); err != nil {
+		return "", false, err
+	}
+	return buf.String(), true, nil
+}
+
+// compressStream encodes the streamed body through a pipe unless its length is known to be smaller than the minimal size.
+// The length of the encoded body is unknown, it's sent in chunks.
+func (c Compression) compressStream(rb *requestBody) (*requestBody, bool, error) {
+	if c.Encoding == "" || (rb.length > 0 && rb.length < int64(c.minSize())) {
+		return rb, false, nil
+	}
+	if _, err := c.writer(io.Discard); err != nil {
+		return nil, false, err
+	}
+	return &requestBody{
+		contentType: rb.contentType,
+		replayable:  rb.replayable,
+		open: func() (io.Reader, error) {
+			src, err := rb.open()
+			if err != nil {
+				return nil, err
+			}
+			pr, pw := io.Pipe()
+			go func() {
+				w, _ := c.writer(pw)
+				_, err := io.Copy(w, src)
+				if err == nil {
+					err = w.Close()
+				}
+				// stops the writer of the source if the pipe was closed before it ended.
+				if closer, ok := src.(io.Closer); ok {
+					closer.Close()
+				}
+				// the error is returned to the reader of the body.
+				pw.CloseWithError(err)
+			}()
+			return pr, nil
+		},
+	}, true, nil
+}
+
+// decodeResponse replaces the gzip or deflate encoded body with the decoded one.
+// The transport decodes gzip itself only if the caller didn't set Accept-Encoding.
+func decodeResponse(res *http.Response) {
+	enc := strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding")))
+	if enc != "gzip" && enc != "deflate" {
+		return
+	}
+	res.Body = &decodingBody{body: res.Body, encoding: enc}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+// decodingBody creates the decoder on the first read, so that empty bodies can be closed without errors.
+type decodingBody struct {
+	body     io.ReadCloser
+	encoding string
+	decoder  io.Reader
+	err      error
+}
+
+func (b *decodingBody) Read(p []byte) (int, error) {
+	if b.decoder == nil && b.err == nil {
+		b.decoder, b.err = newDecoder(b.body, b.encoding)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.decoder.Read(p)
+}
+
+func (b *decodingBody) Close() error {
+	if c, ok := b.decoder.(io.Closer); ok {
+		c.Close()
+	}
+	return b.body.Close()
+}
+
+func newDecoder(r io.Reader, encoding string) (io.Reader, error) {
+	if encoding == "gzip" {
+		return gzip.NewReader(r)
+	}
+	// deflate is supposed to be in zlib format, but some servers send raw deflate.
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decodeRequest replaces the gzip or deflate encoded body of the request with the decoded one.
+func decodeRequest(r *http.Request) error {
+	enc := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if enc == "" || enc == "identity" {
+		return nil
+	}
+	if enc != "gzip" && enc != "deflate" {
+		return fmt.Errorf("unsupported Content-Encoding %q", enc)
+	}
+	r.Body = &decodingBody{body: r.Body, encoding: enc}
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+	return nil
+}
+
+// acceptsGzip checks if the Accept-Encoding header allows gzip.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		q := 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			}
+		}
+		return q > 0
+	}
+	return false
+}
+
+func gzipString(s string) (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(w, s); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,164 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_Compression(t *testing.T) {
+	m := &Mock{}
+	m.On("POST", "pets.io/pets").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		enc := req.Header.Get("Content-Encoding")
+		var r io.Reader = req.Body
+		var err error
+		switch enc {
+		case "gzip":
+			r, err = gzip.NewReader(req.Body)
+		case "deflate":
+			r, err = zlib.NewReader(req.Body)
+		}
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return mockResponse(req, 200, nil, []byte(enc+" "+string(b))), nil
+	})
+	c := &Client{HttpClient: m.HttpClient()}
+	long := strings.Repeat("a", 2000)
+
+	res, err := PostWith[string](c, "pets.io/pets", long, Config{Compression: Compression{Encoding: "gzip"}})
+	assert(t, err, nil)
+	assert(t, res, "gzip "+long)
+
+	res, err = PostWith[string](c, "pets.io/pets", long, Config{Compression: Compression{Encoding: "deflate"}})
+	assert(t, err, nil)
+	assert(t, res, "deflate "+long)
+
+	res, err = PostWith[string](c, "pets.io/pets", "short", Config{Compression: Compression{Encoding: "gzip"}})
+	assert(t, err, nil)
+	assert(t, res, " short")
+
+	_, err = PostWith[string](c, "pets.io/pets", long, Config{Compression: Compression{Encoding: "br"}})
+	assertNotNil(t, err)
+}
+
+func TestConfig_CompressionStreamed(t *testing.T) {
+	var calls int
+	m := &Mock{}
+	m.On("POST", "pets.io/upload").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		r, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if calls == 1 {
+			return mockResponse(req, 503, nil, nil), nil
+		}
+		return mockResponse(req, 200, nil, []byte(strconv.FormatInt(req.ContentLength, 10)+" "+string(b))), nil
+	})
+	c := &Client{HttpClient: m.HttpClient()}
+	gz := Compression{Encoding: "gzip"}
+	long := strings.Repeat("a", 2000)
+
+	// the retried attempt is compressed again.
+	res, err := PostWith[string](c, "pets.io/upload", strings.NewReader(long), Config{
+		Compression: gz,
+		Retry:       Retry{Attempts: 2, Backoff: time.Millisecond},
+	})
+	assert(t, err, nil)
+	assert(t, res, "0 "+long)
+
+	// the length is unknown, the body is compressed regardless of MinSize.
+	calls = 1
+	res, err = PostWith[string](c, "pets.io/upload", io.MultiReader(strings.NewReader("short")), Config{Compression: gz, ContentLength: 5})
+	assert(t, err, nil)
+	assert(t, res, "0 short")
+
+	calls = 1
+	res, err = PostWith[string](c, "pets.io/upload", Multipart{Fields: map[string]string{"name": long}}, Config{Compression: gz})
+	assert(t, err, nil)
+	if !strings.Contains(res, long) {
+		t.Errorf("wrong multipart body: %s", res)
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	var gz, zl, raw bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(`{"name":"Lola"}`))
+	gw.Close()
+	zw := zlib.NewWriter(&zl)
+	zw.Write([]byte(`{"name":"Max"}`))
+	zw.Close()
+	fw, _ := flate.NewWriter(&raw, flate.DefaultCompression)
+	fw.Write([]byte(`{"name":"Charles"}`))
+	fw.Close()
+
+	m := &Mock{}
+	m.On("GET", "pets.io/gzip").Reply(200, gz.Bytes()).ReplyHeader("Content-Encoding", "gzip")
+	m.On("GET", "pets.io/zlib").Reply(200, zl.Bytes()).ReplyHeader("Content-Encoding", "deflate")
+	m.On("GET", "pets.io/raw").Reply(200, raw.Bytes()).ReplyHeader("Content-Encoding", "deflate")
+	m.On("HEAD", "pets.io/gzip").Reply(200, nil).ReplyHeader("Content-Encoding", "gzip")
+	c := &Client{HttpClient: m.HttpClient()}
+
+	res, err := GetWith[Response[Pet]](c, "pets.io/gzip", Config{Headers: map[string]string{"Accept-Encoding": "gzip"}})
+	assert(t, err, nil)
+	assert(t, res.Body.Name, "Lola")
+	assert(t, res.Headers["Content-Encoding"], "")
+	pet, err := GetWith[Pet](c, "pets.io/zlib")
+	assert(t, err, nil)
+	assert(t, pet.Name, "Max")
+	pet, err = GetWith[Pet](c, "pets.io/raw")
+	assert(t, err, nil)
+	assert(t, pet.Name, "Charles")
+	_, err = HeadWith[Empty](c, "pets.io/gzip")
+	assert(t, err, nil)
+}
+
+func TestToHandlerFunc_Compression(t *testing.T) {
+	long := strings.Repeat("a", 2000)
+	f := ToHandlerFunc(func(in Pet) (Pet, error) {
+		return Pet{Name: in.Name + long}, nil
+	})
+	var body bytes.Buffer
+	gw := gzip.NewWriter(&body)
+	gw.Write([]byte(`{"name":"Lola"}`))
+	gw.Close()
+	r, err := http.NewRequest("POST", "/pets", &body)
+	assert(t, err, nil)
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	mw := newMockWriter()
+	f(mw, r)
+	assert(t, mw.status, 200)
+	assert(t, mw.Header().Get("Content-Encoding"), "gzip")
+	assert(t, mw.Header().Get("Vary"), "Accept-Encoding")
+	gr, err := gzip.NewReader(strings.NewReader(mw.body))
+	assert(t, err, nil)
+	b, err := io.ReadAll(gr)
+	assert(t, err, nil)
+	assert(t, string(b), `{"name":"Lola`+long+`"}`)
+
+	r, err = http.NewRequest("POST", "/pets", strings.NewReader(`{"name":"Lola"}`))
+	assert(t, err, nil)
+	r.Header.Set("Accept-Encoding", "gzip;q=0")
+	mw = newMockWriter()
+	f(mw, r)
+	assert(t, mw.Header().Get("Content-Encoding"), "")
+	assert(t, mw.body, `{"name":"Lola`+long+`"}`)
+}
@@ -0,0 +1,56 @@
+package fetch
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+)
+
+func TestClient_Jar(t *testing.T) {
+	m := &Mock{}
+	m.On("POST", "pets.io/login").Reply(204, nil).ReplyHeader("Set-Cookie", "session=abc; Path=/; HttpOnly")
+	m.On("GET", "pets.io/me").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(req, 200, nil, []byte(req.Header.Get("Cookie"))), nil
+	})
+	jar, err := cookiejar.New(nil)
+	assert(t, err, nil)
+	c := &Client{HttpClient: m.HttpClient(), Jar: jar}
+
+	res, err := PostWith[ResponseEmpty](c, "pets.io/login", nil)
+	assert(t, err, nil)
+	if len(res.Cookies) != 1 || res.Cookies[0].Name != "session" || res.Cookies[0].Value != "abc" || !res.Cookies[0].HttpOnly {
+		t.Fatalf("wrong cookies: %v", res.Cookies)
+	}
+
+	me, err := GetWith[string](c, "pets.io/me")
+	assert(t, err, nil)
+	assert(t, me, "session=abc")
+
+	// the jar isn't shared with the other clients of the same http.Client.
+	me, err = GetWith[string](&Client{HttpClient: m.HttpClient()}, "pets.io/me")
+	assert(t, err, nil)
+	assert(t, me, "")
+}
+
+func TestToHandlerFunc_Cookies(t *testing.T) {
+	f := ToHandlerFunc(func(in Request[Empty]) (Response[Empty], error) {
+		if len(in.Cookies) != 1 || in.Cookies[0].Value != "abc" {
+			t.Errorf("wrong request cookies: %v", in.Cookies)
+		}
+		return Response[Empty]{Status: 200, Cookies: []*http.Cookie{
+			{Name: "session", Value: "", MaxAge: -1},
+			{Name: "theme", Value: "dark", Path: "/", Secure: true, SameSite: http.SameSiteStrictMode},
+		}}, nil
+	})
+	r, err := http.NewRequest("GET", "/", nil)
+	assert(t, err, nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	mw := newMockWriter()
+	f(mw, r)
+	got := mw.Header().Values("Set-Cookie")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 cookies, got %v", got)
+	}
+	assert(t, got[0], "session=; Max-Age=0")
+	assert(t, got[1], "theme=dark; Path=/; Secure; SameSite=Strict")
+}
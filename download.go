@@ -0,0 +1,266 @@
+package fetch
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DownloadConfig is Config with the download settings.
+type DownloadConfig struct {
+	Config
+	// Checksum of the file in the format algorithm:hex e.g. sha256:2cf24d...
+	// Supported algorithms are md5, sha1, sha256 and sha512.
+	Checksum string
+	// Progress is called after every written chunk. Total is -1 if the size is unknown.
+	Progress func(downloaded, total int64)
+	// Number of attempts to resume the interrupted download with a Range request.
+	// Defaults to 3, negative disables resuming.
+	Resumes int
+}
+
+/*
+Download saves the response body into the file without loading it into memory.
+The body is written into a temporary file in the same directory,
+which is renamed to the path once the download is complete and verified.
+If the connection breaks, the download is resumed from the written bytes
+with Range and If-Range headers.
+e.g.
+
+	err := fetch.Download("https://example.com/backup.tar.gz", "backup.tar.gz", fetch.DownloadConfig{
+		Checksum: "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		Progress: func(downloaded, total int64) {
+			fmt.Printf("\r%d/%d", downloaded, total)
+		},
+	})
+
+HTTP failures are returned as *fetch.Error.
+*/
+func Download(url, path string, config ...DownloadConfig) error {
+	return DownloadWith(defaultClient, url, path, config...)
+}
+
+// DownloadWith is Download made with the settings of the client.
+func DownloadWith(c *Client, url, path string, config ...DownloadConfig) error {
+	if c == nil {
+		c = defaultClient
+	}
+	var cfg DownloadConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Resumes == 0 {
+		cfg.Resumes = 3
+	}
+	var hasher hash.Hash
+	var want string
+	if cfg.Checksum != "" {
+		var err error
+		hasher, want, err = parseChecksum(cfg.Checksum)
+		if err != nil {
+			return nonHttpErr("invalid checksum: ", err)
+		}
+	}
+
+	tmp, err := createPart(path)
+	if err != nil {
+		return nonHttpErr("download: ", err)
+	}
+	done := false
+	defer func() {
+		if !done {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	d := &download{file: tmp, hasher: hasher, total: -1, progress: cfg.Progress}
+	for resumes := 0; ; resumes++ {
+		ferr, interrupted := d.fetch(c, url, cfg.Config)
+		if ferr == nil {
+			break
+		}
+		if !interrupted || resumes >= cfg.Resumes || d.validator == "" {
+			return ferr
+		}
+	}
+
+	if d.total >= 0 && d.written != d.total {
+		return nonHttpErr("download: ", fmt.Errorf("got %d bytes, expected %d", d.written, d.total))
+	}
+	if hasher != nil {
+		if got := hex.EncodeToString(hasher.Sum(nil)); got != want {
+			return nonHttpErr("download: ", fmt.Errorf("checksum mismatch, got %s, expected %s", got, want))
+		}
+	}
+	if err = tmp.Sync(); err != nil {
+		return nonHttpErr("download: ", err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		// the replaced file keeps its mode.
+		if err = tmp.Chmod(info.Mode().Perm()); err != nil {
+			return nonHttpErr("download: ", err)
+		}
+	}
+	if err = tmp.Close(); err != nil {
+		return nonHttpErr("download: ", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return nonHttpErr("download: ", err)
+	}
+	done = true
+	return nil
+}
+
+// createPart creates the temporary file next to the path. Unlike os.CreateTemp,
+// its mode is 0666 masked by the umask like the files of os.Create.
+func createPart(path string) (*os.File, error) {
+	for i := 0; ; i++ {
+		name := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.%d.part", filepath.Base(path), rand.Uint32()))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && i < 10000 {
+			continue
+		}
+		return f, err
+	}
+}
+
+type download struct {
+	file    *os.File
+	hasher  hash.Hash
+	written int64
+	total   int64
+	// ETag or Last-Modified of the first response, sent in If-Range.
+	validator string
+	progress  func(downloaded, total int64)
+}
+
+// fetch requests the rest of the file and writes it.
+// interrupted is true if the error happened while reading the body.
+func (d *download) fetch(c *Client, url string, cfg Config) (ferr *Error, interrupted bool) {
+	if d.written > 0 {
+		cfg.Headers = mapWith(cfg.Headers, "Range", fmt.Sprintf("bytes=%d-", d.written))
+		cfg.Headers["If-Range"] = d.validator
+	}
+	ex, ferr := c.exchange(url, cfg)
+	if ferr != nil {
+		return ferr, false
+	}
+	defer ex.close()
+	res := ex.res
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		// the file changed or the server doesn't support ranges.
+		if err := d.reset(); err != nil {
+			return ex.fail(nonHttpErr("download: ", err)), false
+		}
+		d.total = res.ContentLength
+		d.validator = res.Header.Get("ETag")
+		if d.validator == "" || strings.HasPrefix(d.validator, "W/") {
+			d.validator = res.Header.Get("Last-Modified")
+		}
+	case http.StatusPartialContent:
+		start, total, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil {
+			return ex.fail(httpErr("download: ", err, res, nil)), false
+		}
+		if start != d.written {
+			return ex.fail(httpErr("download: ", fmt.Errorf("range starts at %d, expected %d", start, d.written), res, nil)), false
+		}
+		d.total = total
+	default:
+		body, err := ex.readBody(res.Body)
+		if err != nil {
+			return ex.fail(httpErr("read http body: ", err, res, nil)), false
+		}
+		return ex.fail(statusErr(res, body)), false
+	}
+
+	_, err := io.Copy(d, res.Body)
+	if err != nil {
+		return ex.fail(httpErr("download: ", err, res, nil)), cfg.Ctx == nil || cfg.Ctx.Err() == nil
+	}
+	return nil, false
+}
+
+func (d *download) Write(p []byte) (int, error) {
+	n, err := d.file.Write(p)
+	if d.hasher != nil {
+		d.hasher.Write(p[:n])
+	}
+	d.written += int64(n)
+	if d.progress != nil && n > 0 {
+		d.progress(d.written, d.total)
+	}
+	return n, err
+}
+
+func (d *download) reset() error {
+	if d.written == 0 {
+		return nil
+	}
+	if err := d.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if d.hasher != nil {
+		d.hasher.Reset()
+	}
+	d.written = 0
+	return nil
+}
+
+// parseContentRange parses the start and the total size from "bytes start-end/total".
+func parseContentRange(v string) (int64, int64, error) {
+	rng, found := strings.CutPrefix(v, "bytes ")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", v)
+	}
+	rng, size, _ := strings.Cut(rng, "/")
+	first, _, _ := strings.Cut(rng, "-")
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", v)
+	}
+	total := int64(-1)
+	if size != "*" {
+		total, err = strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", v)
+		}
+	}
+	return start, total, nil
+}
+
+func parseChecksum(checksum string) (hash.Hash, string, error) {
+	alg, sum, ok := strings.Cut(checksum, ":")
+	if !ok {
+		return nil, "", fmt.Errorf("expected algorithm:hex, got %q", checksum)
+	}
+	sum = strings.ToLower(sum)
+	switch strings.ToLower(alg) {
+	case "md5":
+		return md5.New(), sum, nil
+	case "sha1":
+		return sha1.New(), sum, nil
+	case "sha256":
+		return sha256.New(), sum, nil
+	case "sha512":
+		return sha512.New(), sum, nil
+	}
+	return nil, "", fmt.Errorf("unsupported algorithm %q", alg)
+}
@@ -0,0 +1,114 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownload_Resume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	sum := sha256.Sum256(content)
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		if n == 1 {
+			// breaks the connection in the middle of the body.
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:len(content)/3])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		if r.Header.Get("Range") != "bytes=33333-" || r.Header.Get("If-Range") != `"v1"` {
+			t.Errorf("wrong range headers: %v", r.Header)
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	var lastProgress, lastTotal int64
+	err := DownloadWith(&Client{}, ts.URL, path, DownloadConfig{
+		Checksum: "sha256:" + hex.EncodeToString(sum[:]),
+		Progress: func(downloaded, total int64) {
+			lastProgress, lastTotal = downloaded, total
+		},
+	})
+	assert(t, err, nil)
+	got, err := os.ReadFile(path)
+	assert(t, err, nil)
+	assert(t, bytes.Equal(got, content), true)
+	assert(t, requests.Load(), int32(2))
+	assert(t, lastProgress, int64(len(content)))
+	assert(t, lastTotal, int64(len(content)))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert(t, err, nil)
+	assert(t, len(entries), 1)
+}
+
+func TestDownload_Failures(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "files.io/file.txt").Reply(200, "hello")
+	m.On("GET", "files.io/missing.txt").Reply(404, "not found")
+	c := &Client{HttpClient: m.HttpClient()}
+	dir := t.TempDir()
+
+	err := DownloadWith(c, "files.io/file.txt", filepath.Join(dir, "file.txt"), DownloadConfig{Checksum: "sha256:abc"})
+	assertNotNil(t, err)
+	err = DownloadWith(c, "files.io/file.txt", filepath.Join(dir, "file.txt"), DownloadConfig{Checksum: "crc:abc"})
+	assertNotNil(t, err)
+	err = DownloadWith(c, "files.io/missing.txt", filepath.Join(dir, "missing.txt"))
+	var ferr *Error
+	if !errors.As(err, &ferr) || ferr.Status != 404 {
+		t.Fatalf("expected 404 error, got %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	assert(t, err, nil)
+	assert(t, len(entries), 0)
+
+	err = DownloadWith(c, "files.io/file.txt", filepath.Join(dir, "file.txt"), DownloadConfig{Checksum: "md5:5D41402ABC4B2A76B9719D911017C592"})
+	assert(t, err, nil)
+	got, err := os.ReadFile(filepath.Join(dir, "file.txt"))
+	assert(t, err, nil)
+	assert(t, string(got), "hello")
+}
+
+func TestDownload_FileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are unix permissions")
+	}
+	m := &Mock{}
+	m.On("GET", "files.io/file.txt").Reply(200, "content")
+	c := &Client{HttpClient: m.HttpClient()}
+	dir := t.TempDir()
+
+	// the new file has the mode of os.Create.
+	created, err := os.Create(filepath.Join(dir, "created.txt"))
+	assert(t, err, nil)
+	created.Close()
+	want, err := os.Stat(created.Name())
+	assert(t, err, nil)
+	path := filepath.Join(dir, "file.txt")
+	assert(t, DownloadWith(c, "files.io/file.txt", path), nil)
+	info, err := os.Stat(path)
+	assert(t, err, nil)
+	assert(t, info.Mode().Perm(), want.Mode().Perm())
+
+	// the replaced file keeps its mode.
+	assert(t, os.Chmod(path, 0640), nil)
+	assert(t, DownloadWith(c, "files.io/file.txt", path), nil)
+	info, err = os.Stat(path)
+	assert(t, err, nil)
+	assert(t, info.Mode().Perm(), os.FileMode(0640))
+}
@@ -1,15 +1,26 @@
 package fetch
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 )
 
 type Error struct {
-	inner   error
-	Msg     string
-	Status  int
+	inner  error
+	Msg    string
+	Status int
+	// HTTP headers with the last value of each.
 	Headers map[string]string
-	Body    string
+	// HTTP headers with all the values.
+	Header http.Header
+	Body   string
+	// Number of the made HTTP requests, more than one if the request was retried.
+	Attempts int
+	// Errors of every attempt, the last one is the Error itself.
+	AttemptErrors []error
+	// Timings of the request if Config.Trace is set, nil otherwise.
+	Timings *Timings
 }
 
 func (e *Error) Error() string {
@@ -26,6 +37,15 @@ func (e *Error) Unwrap() error {
 	return e.inner
 }
 
+// SizeError is the cause of *Error when the body exceeds the size limit.
+type SizeError struct {
+	Limit int64
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("body exceeds the limit of %d bytes", e.Limit)
+}
+
 func nonHttpErr(prefix string, err error) *Error {
 	return &Error{inner: err, Msg: prefix + err.Error()}
 }
@@ -39,6 +59,17 @@ func httpErr(prefix string, err error, r *http.Response, body []byte) *Error {
 		Msg:     prefix + err.Error(),
 		Status:  r.StatusCode,
 		Headers: mapFlatten(r.Header),
+		Header:  r.Header,
 		Body:    string(body),
 	}
 }
+
+func statusErr(r *http.Response, body []byte) *Error {
+	return httpErr(fmt.Sprintf("http response with status=%d, body: ", r.StatusCode), errors.New(string(body)), r, body)
+}
+
+func withAttempts(err *Error, failed []error) *Error {
+	err.Attempts = len(failed) + 1
+	err.AttemptErrors = append(failed[:len(failed):len(failed)], err)
+	return err
+}
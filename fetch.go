@@ -4,18 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"reflect"
 	"strings"
 	"time"
 )
 
-var httpClient = &http.Client{}
-var baseURL = ""
-
 type Config struct {
 	// Defaults to context.Background()
 	Ctx context.Context
@@ -25,87 +22,188 @@ type Config struct {
 	Method  string
 	Body    string
 	Headers map[string]string
+	// Header sets multi-valued headers. Its values replace the ones of Headers with the same key.
+	Header http.Header
+	// Retry repeats failed requests. Defaults to Client.Retry.
+	Retry Retry
+	// Interceptors are called after the ones of the client.
+	Interceptors []Interceptor
+	// Auth authenticates the request. Defaults to Client.Auth.
+	Auth Interceptor
+	// ContentLength is the length of the io.Reader body if it's known.
+	// The length of io.Seeker bodies is measured, others are sent in chunks.
+	ContentLength int64
+	// UploadProgress is called while the request body is being sent. Total is -1 if the length is unknown.
+	UploadProgress func(sent, total int64)
+	// Trace records Timings of the request into Response and Error.
+	Trace bool
+	// MaxResponseSize limits the size of the response body in bytes, *SizeError is returned if it's exceeded.
+	// Streamed bodies aren't limited. Zero means no limit.
+	MaxResponseSize int64
+	// Compression encodes the request body with gzip or deflate, including Form, Multipart and io.Reader bodies,
+	// which are compressed while they're streamed, with unknown length.
+	// gzip and deflate responses are decoded regardless of it.
+	Compression Compression
+	// PathParams substitute the placeholders in the URL path e.g. {id} in /pets/{id}.
+	// The values are strings, numbers, booleans, time.Time or encoding.TextMarshaler.
+	// Missing and unused parameters fail the request. Without PathParams the URL is sent as is. See Route.
+	PathParams map[string]any
+	// Query parameters added to the URL, replacing the ones with the same keys.
+	// Accepts a map or a struct encoded with the rules of MarshalForm,
+	// i.e. lowercased field names, omitted empty fields, slices as repeated keys.
+	Query any
+
+	// streamed body, replaces Body.
+	body *requestBody
 }
 
 func Get[T any](url string, config ...Config) (T, error) {
-	if len(config) == 0 {
-		config = []Config{{}}
-	}
-	config[0].Method = http.MethodGet
-	return Do[T](url, config...)
+	return GetWith[T](defaultClient, url, config...)
 }
 
 func Post[T any](url string, body any, config ...Config) (T, error) {
-	return requestWithBody[T](url, http.MethodPost, body, config...)
+	return PostWith[T](defaultClient, url, body, config...)
 }
 
 func Put[T any](url string, body any, config ...Config) (T, error) {
-	return requestWithBody[T](url, http.MethodPut, body, config...)
+	return PutWith[T](defaultClient, url, body, config...)
 }
 
 func Patch[T any](url string, body any, config ...Config) (T, error) {
-	return requestWithBody[T](url, http.MethodPatch, body, config...)
+	return PatchWith[T](defaultClient, url, body, config...)
 }
 
-func requestWithBody[T any](url string, method string, body any, config ...Config) (T, error) {
+func requestWithBody[T any](c *Client, url string, method string, body any, config ...Config) (T, error) {
 	if len(config) == 0 {
 		config = []Config{{}}
 	}
 	config[0].Method = method
-	b, err := bodyToString(body)
+	rb, ok, err := newRequestBody(body)
+	if err != nil {
+		var t T
+		return t, nonHttpErr("invalid body: ", err)
+	}
+	if ok {
+		config[0].body = rb
+		return DoWith[T](c, url, config...)
+	}
+	b, err := bodyToString(body, contentTypeOf(config[0]))
 	if err != nil {
 		var t T
 		return t, nonHttpErr("invalid body: ", err)
 	}
 	config[0].Body = b
-	return Do[T](url, config...)
+	return DoWith[T](c, url, config...)
 }
 
-func bodyToString(v any) (string, error) {
+// bodyToString marshals the body with the codec of the content type, JSON is the default.
+func bodyToString(v any, contentType string) (string, error) {
 	if s, ok := v.(string); ok {
 		return s, nil
 	}
 	if s, ok := v.([]byte); ok {
 		return string(s), nil
 	}
+	if c, ok := codecFor(contentType); ok {
+		b, err := c.Marshal(v)
+		return string(b), err
+	}
 	return Marshal(v)
 }
 
-func Delete[T any](url string, config ...Config) (T, error) {
-	if len(config) == 0 {
-		config = []Config{{}}
+func contentTypeOf(cfg Config) string {
+	if v := cfg.Header.Get("Content-Type"); v != "" {
+		return v
+	}
+	for k, v := range cfg.Headers {
+		if http.CanonicalHeaderKey(k) == "Content-Type" {
+			return v
+		}
 	}
-	config[0].Method = http.MethodDelete
-	return Do[T](url, config...)
+	return ""
+}
+
+func Delete[T any](url string, config ...Config) (T, error) {
+	return DeleteWith[T](defaultClient, url, config...)
 }
 
 func Head[T any](url string, config ...Config) (T, error) {
-	if len(config) == 0 {
-		config = []Config{{}}
-	}
-	config[0].Method = http.MethodHead
-	return Do[T](url, config...)
+	return HeadWith[T](defaultClient, url, config...)
 }
 
 func Options[T any](url string, config ...Config) (T, error) {
-	if len(config) == 0 {
-		config = []Config{{}}
-	}
-	config[0].Method = http.MethodOptions
-	return Do[T](url, config...)
+	return OptionsWith[T](defaultClient, url, config...)
 }
 
 func Do[T any](url string, config ...Config) (T, error) {
+	return DoWith[T](defaultClient, url, config...)
+}
+
+// DoWith makes the HTTP request with the settings of the client.
+// A nil client is the default one.
+func DoWith[T any](c *Client, url string, config ...Config) (T, error) {
+	var t T
+	if c == nil {
+		c = defaultClient
+	}
 	var cfg Config
 	if len(config) > 0 {
 		cfg = config[0]
 	}
+	ex, ferr := c.exchange(url, cfg)
+	if ferr != nil {
+		return t, ferr
+	}
+	if isStreamType[T]() && firstDigit(ex.res.StatusCode) == 2 {
+		return streamResponse[T](ex), nil
+	}
+	defer ex.close()
+
+	t, ferr = readResponse[T](ex)
+	if ferr != nil {
+		return t, ex.fail(ferr)
+	}
+	return t, nil
+}
+
+// exchange is a sent request and its response with the unread body.
+type exchange struct {
+	client *Client
+	req    *http.Request
+	res    *http.Response
+	// errors of the failed attempts before the response.
+	failed []error
+	cancel context.CancelFunc
+	// the response was served by Cache.
+	cached bool
+	// limit of the response body size.
+	maxSize int64
+	// nil unless Config.Trace is set.
+	trace *tracer
+	// number of the current attempt.
+	attempt int
+	// request bodies opened for the attempts, closed after sending.
+	bodies []io.Closer
+}
+
+type exchangeKey struct{}
 
+// exchangeOf returns the exchange of the request made by fetch, nil otherwise.
+func exchangeOf(req *http.Request) *exchange {
+	ex, _ := req.Context().Value(exchangeKey{}).(*exchange)
+	return ex
+}
+
+// exchange sends the request and returns the response with the unread body.
+// The exchange must be closed after reading the body.
+func (c *Client) exchange(url string, cfg Config) (*exchange, *Error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = c.Timeout
+	}
+	cancel := context.CancelFunc(func() {})
 	if cfg.Ctx == nil {
 		if cfg.Timeout > 0 {
-			var cancel context.CancelFunc
 			cfg.Ctx, cancel = context.WithTimeout(context.Background(), cfg.Timeout)
-			defer cancel()
 		} else {
 			cfg.Ctx = context.Background()
 		}
@@ -113,14 +211,21 @@ func Do[T any](url string, config ...Config) (T, error) {
 	if cfg.Method == "" {
 		cfg.Method = "GET"
 	}
-	if cfg.Headers == nil || !hasContentType(cfg) {
-		if cfg.Headers == nil {
-			cfg.Headers = make(map[string]string, 1)
+	ex := &exchange{client: c, cancel: cancel, maxSize: cfg.MaxResponseSize}
+	cfg.Ctx = context.WithValue(withRoute(cfg.Ctx, url), exchangeKey{}, ex)
+	if cfg.Trace {
+		ex.trace = newTracer()
+		cfg.Ctx = httptrace.WithClientTrace(cfg.Ctx, ex.trace.clientTrace())
+	}
+	if cfg.PathParams != nil {
+		var err error
+		url, err = expandPath(url, cfg.PathParams)
+		if err != nil {
+			cancel()
+			return nil, nonHttpErr("invalid path: ", err)
 		}
-		cfg.Headers["Content-type"] = "application/json"
 	}
-
-	fullURL := baseURL + url
+	fullURL := c.BaseURL + url
 	if hasProtocol(url) {
 		fullURL = url
 	}
@@ -132,40 +237,182 @@ func Do[T any](url string, config ...Config) (T, error) {
 		}
 	}
 
-	req, err := http.NewRequest(cfg.Method, fullURL, bytes.NewBuffer([]byte(cfg.Body)))
+	if cfg.Query != nil {
+		var err error
+		fullURL, err = withQuery(fullURL, cfg.Query)
+		if err != nil {
+			cancel()
+			return nil, nonHttpErr("invalid query: ", err)
+		}
+	}
+
+	compressed := false
+	if cfg.body == nil {
+		var err error
+		cfg.Body, compressed, err = cfg.Compression.compress(cfg.Body)
+		if err != nil {
+			cancel()
+			return nil, nonHttpErr("invalid compression: ", err)
+		}
+	} else {
+		var err error
+		cfg.body, compressed, err = cfg.Compression.compressStream(cfg.body)
+		if err != nil {
+			cancel()
+			return nil, nonHttpErr("invalid compression: ", err)
+		}
+	}
+
+	var body io.Reader = bytes.NewBuffer([]byte(cfg.Body))
+	if cfg.body != nil {
+		var err error
+		body, err = ex.openBody(cfg.body)
+		if err != nil {
+			cancel()
+			return nil, nonHttpErr("invalid body: ", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(cfg.Ctx, cfg.Method, fullURL, body)
 	if err != nil {
-		var t T
-		return t, nonHttpErr("invalid request: ", err)
+		if closer, ok := body.(io.Closer); ok {
+			closer.Close()
+		}
+		cancel()
+		return nil, nonHttpErr("invalid request: ", err)
 	}
 
-	req = req.WithContext(cfg.Ctx)
+	if cfg.body != nil {
+		// the length of the compressed body is unknown.
+		if cfg.ContentLength > 0 && !compressed {
+			req.ContentLength = cfg.ContentLength
+		} else if cfg.body.length > 0 {
+			req.ContentLength = cfg.body.length
+		}
+		if cfg.body.replayable {
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, err := ex.openBody(cfg.body)
+				return io.NopCloser(body), err
+			}
+		}
+	}
+	if cfg.UploadProgress != nil {
+		withUploadProgress(req, cfg.UploadProgress)
+	}
 
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
 	for k, v := range cfg.Headers {
 		req.Header.Set(k, v)
 	}
+	setHeader(req.Header, cfg.Header)
+	if req.Header.Get("Content-Type") == "" {
+		if cfg.body != nil && cfg.body.contentType != "" {
+			req.Header.Set("Content-Type", cfg.body.contentType)
+		} else {
+			req.Header.Set("Content-type", "application/json")
+		}
+	}
 
-	var res *http.Response
-	if mock {
-		res = mockDNS(url, req).response()
-	} else {
-		res, err = httpClient.Do(req)
-		if err != nil {
-			var t T
-			return t, nonHttpErr("failed request: ", err)
+	if compressed {
+		req.Header.Set("Content-Encoding", cfg.Compression.Encoding)
+	}
+
+	retry := cfg.Retry
+	if retry.Attempts == 0 {
+		retry = c.Retry
+	}
+	interceptors := append(c.Interceptors[:len(c.Interceptors):len(c.Interceptors)], cfg.Interceptors...)
+	if cfg.Auth == nil {
+		cfg.Auth = c.Auth
+	}
+	if cfg.Auth != nil {
+		interceptors = append(interceptors, cfg.Auth)
+	}
+	if c.Logger != nil {
+		interceptors = append([]Interceptor{c.logRequest}, interceptors...)
+	}
+	start := time.Now()
+	if c.Metrics != nil {
+		c.Metrics.RequestStart(MetricsEvent{Method: req.Method, Route: Route(req)})
+	}
+	res, failed, err := c.send(req, retry, chain(interceptors, c.roundTrip))
+	ex.closeBodies()
+	if err != nil {
+		cancel()
+		ferr, ok := err.(*Error)
+		if !ok {
+			ferr = nonHttpErr("failed request: ", err)
+		}
+		ferr.Timings = ex.trace.result()
+		ferr = withAttempts(ferr, failed)
+		if c.Metrics != nil {
+			c.observeRequest(req, nil, ferr, start)
 		}
+		return nil, ferr
 	}
+	if c.Metrics != nil {
+		c.observeRequest(req, res, nil, start)
+	}
+	ex.req, ex.res, ex.failed = req, res, failed
+	return ex, nil
+}
 
-	defer func() {
-		if res != nil && res.Body != nil {
-			// the body needs to be closed even it wasn't read.
-			err := res.Body.Close()
-			if err != nil {
-				fmt.Println(fmt.Sprintf("resource leak: fetch %s failed to close the response body: %s", req.URL.String(), err.Error()))
-			}
+// openBody opens the request body, keeping it to be closed after sending.
+func (ex *exchange) openBody(rb *requestBody) (io.Reader, error) {
+	body, err := rb.open()
+	if closer, ok := body.(io.Closer); ok {
+		ex.bodies = append(ex.bodies, closer)
+	}
+	return body, err
+}
+
+// closeBodies closes the request bodies, stopping the writers of the ones which weren't read,
+// e.g. when an interceptor responds without sending the request.
+func (ex *exchange) closeBodies() {
+	for _, b := range ex.bodies {
+		b.Close()
+	}
+	ex.bodies = nil
+}
+
+// close closes the response body and releases the request context.
+func (ex *exchange) close() {
+	defer ex.cancel()
+	if ex.res.Body != nil {
+		// the body needs to be closed even it wasn't read.
+		err := ex.res.Body.Close()
+		if err != nil {
+			ex.client.errorHook(fmt.Errorf("resource leak: fetch %s failed to close the response body: %s", ex.req.URL.String(), err))
 		}
-	}()
+	}
+}
+
+// readBody reads the response body within the size limit.
+// It's safe to call on the nil exchange of the requests not made by fetch.
+func (ex *exchange) readBody(r io.Reader) ([]byte, error) {
+	if ex == nil || ex.maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+	b, err := io.ReadAll(io.LimitReader(r, ex.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > ex.maxSize {
+		return nil, &SizeError{Limit: ex.maxSize}
+	}
+	return b, nil
+}
+
+// fail adds the attempts of the exchange to the error.
+func (ex *exchange) fail(err *Error) *Error {
+	err.Timings = ex.trace.result()
+	return withAttempts(err, ex.failed)
+}
 
+func readResponse[T any](ex *exchange) (T, *Error) {
 	var t T
+	res := ex.res
 	typeOf := reflect.TypeOf(t)
 
 	if isEmptyType(t) && firstDigit(res.StatusCode) == 2 {
@@ -175,16 +422,20 @@ func Do[T any](url string, config ...Config) (T, error) {
 		re := any(&t).(*Response[Empty])
 		re.Status = res.StatusCode
 		re.Headers = mapFlatten(res.Header)
+		re.Header = res.Header
+		re.Cookies = res.Cookies()
+		re.Cached = ex.cached
+		re.Timings = ex.trace.result()
 		return t, nil
 	}
 
-	body, err := io.ReadAll(res.Body)
+	body, err := ex.readBody(res.Body)
 	if err != nil {
 		return t, httpErr("read http body: ", err, res, nil)
 	}
 
 	if firstDigit(res.StatusCode) != 2 {
-		return t, httpErr(fmt.Sprintf("http response with status=%d, body: ", res.StatusCode), errors.New(string(body)), res, body)
+		return t, statusErr(res, body)
 	}
 
 	if isResponseWrapper(t) {
@@ -194,7 +445,7 @@ func Do[T any](url string, config ...Config) (T, error) {
 		}
 
 		resInstance := reflect.New(resType.Type).Interface()
-		err = parseBodyInto(body, resInstance)
+		err = parseBodyAs(body, resInstance, res.Header.Get("Content-Type"))
 		if err != nil {
 			var t T
 			return t, httpErr("parse response body: ", err, res, body)
@@ -203,11 +454,15 @@ func Do[T any](url string, config ...Config) (T, error) {
 		valueOf := reflect.Indirect(reflect.ValueOf(&t))
 		valueOf.FieldByName("Status").SetInt(int64(res.StatusCode))
 		valueOf.FieldByName("Headers").Set(reflect.ValueOf(mapFlatten(res.Header)))
+		valueOf.FieldByName("Header").Set(reflect.ValueOf(res.Header))
+		valueOf.FieldByName("Cookies").Set(reflect.ValueOf(res.Cookies()))
+		valueOf.FieldByName("Cached").SetBool(ex.cached)
+		valueOf.FieldByName("Timings").Set(reflect.ValueOf(ex.trace.result()))
 		valueOf.FieldByName("Body").Set(reflect.ValueOf(resInstance).Elem())
 
 		return t, nil
 	}
-	err = parseBodyInto(body, &t)
+	err = parseBodyAs(body, &t, res.Header.Get("Content-Type"))
 	if err != nil {
 		var t T
 		return t, httpErr("parse response body: ", err, res, body)
@@ -215,10 +470,42 @@ func Do[T any](url string, config ...Config) (T, error) {
 	return t, nil
 }
 
+// setHeader replaces the values of dst with the ones of src.
+func setHeader(dst, src http.Header) {
+	for k, vals := range src {
+		dst.Del(k)
+		for _, v := range vals {
+			dst.Add(k, v)
+		}
+	}
+}
+
 func hasProtocol(url string) bool {
 	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
 }
 
+// parseBodyAs parses the body with the codec of the content type, JSON is the default.
+func parseBodyAs(body []byte, v any, contentType string) error {
+	c, ok := codecFor(contentType)
+	if !ok || isRawType(v) {
+		return parseBodyInto(body, v)
+	}
+	if len(body) == 0 {
+		return fmt.Errorf("body is empty")
+	}
+	return c.Unmarshal(body, v)
+}
+
+// isRawType checks if v is a pointer to string or []byte, which get the body as is.
+func isRawType(v any) bool {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return false
+	}
+	rve := rv.Elem()
+	return rve.Kind() == reflect.String || (rve.Kind() == reflect.Slice && rve.Type().Elem().Kind() == reflect.Uint8)
+}
+
 func parseBodyInto(body []byte, v any) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Pointer || rv.IsNil() {
@@ -243,15 +530,17 @@ func parseBodyInto(body []byte, v any) error {
 	return nil
 }
 
+// SetHttpClient sets the http.Client of the default client.
 func SetHttpClient(c *http.Client) {
 	if c == nil {
 		return
 	}
-	httpClient = c
+	defaultClient.HttpClient = c
 }
 
+// SetBaseURL sets the base URL of the default client.
 func SetBaseURL(b string) {
-	baseURL = b
+	defaultClient.BaseURL = b
 }
 
 func firstDigit(n int) int {
@@ -260,12 +549,3 @@ func firstDigit(n int) int {
 	}
 	return i
 }
-
-func hasContentType(c Config) bool {
-	for k := range c.Headers {
-		if strings.ToLower(k) == "content-type" {
-			return true
-		}
-	}
-	return false
-}
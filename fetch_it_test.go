@@ -9,8 +9,7 @@ import (
 )
 
 func TestRequestIntegration(t *testing.T) {
-	mock = false
-	defer func() { mock = true }()
+	withoutMock(t)
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
@@ -70,8 +69,7 @@ func TestRequestIntegration(t *testing.T) {
 }
 
 func TestIssue1(t *testing.T) {
-	mock = false
-	defer func() { mock = true }()
+	withoutMock(t)
 	mux := http.NewServeMux()
 	mux.HandleFunc("/v3/sessions", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
@@ -99,8 +97,7 @@ func TestIssue1(t *testing.T) {
 }
 
 func TestTimeout(t *testing.T) {
-	mock = false
-	defer func() { mock = true }()
+	withoutMock(t)
 	mux := http.NewServeMux()
 	mux.HandleFunc("/delay", func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(time.Minute)
@@ -112,7 +109,8 @@ func TestTimeout(t *testing.T) {
 	defer server.Shutdown(serverCtx)
 	time.Sleep(time.Millisecond)
 
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelCtx()
 	_, err := Post[string]("http://localhost:7349/delay", nil, Config{Ctx: ctx})
 	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
 		t.Fatalf("expected context deadealine, got=%v", err)
@@ -1,17 +1,38 @@
 package fetch
 
 import (
+	"io"
+	"net/http"
 	"os"
 	"slices"
 	"testing"
 )
 
+var testMock = &Mock{FailUnmatched: true}
+
 func TestMain(m *testing.M) {
-	mock = true
+	testMock.On("", "key.value").ReplyHeader("Content-type", "application/json").Reply(200, `{"key":"value"}`)
+	testMock.On("", "array.int").Reply(200, `[1, 2, 3]`)
+	testMock.On("", "my.ip").ReplyHeader("Content-type", "text/plain").Reply(200, `8.8.8.8`)
+	testMock.On("", "400.error").ReplyHeader("Content-type", "text/plain").Reply(400, `Bad Request`)
+	testMock.On("", "echo.me").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		return mockResponse(req, 200, nil, body), nil
+	})
+	SetHttpClient(testMock.HttpClient())
 	code := m.Run()
 	os.Exit(code)
 }
 
+// withoutMock makes the default client send real HTTP requests until the end of the test.
+func withoutMock(t *testing.T) {
+	SetHttpClient(&http.Client{})
+	t.Cleanup(func() { SetHttpClient(testMock.HttpClient()) })
+}
+
 func TestDoString(t *testing.T) {
 	res, err := Do[string]("my.ip")
 	if err != nil {
@@ -81,7 +102,7 @@ func TestDo_ResponseT(t *testing.T) {
 		t.Errorf("wrong status")
 	}
 
-	if res.Headers["Content-type"] != "application/json" {
+	if res.Headers["Content-Type"] != "application/json" {
 		t.Errorf("wrong headers")
 	}
 
@@ -106,7 +127,7 @@ func TestDo_ResponseEmpty(t *testing.T) {
 	if res.Status != 200 {
 		t.Errorf("response status isn't 200")
 	}
-	if res.Headers["Content-type"] != "application/json" {
+	if res.Headers["Content-Type"] != "application/json" {
 		t.Errorf("wrong headers")
 	}
 
@@ -122,7 +143,7 @@ func TestDo_ResponseEmpty(t *testing.T) {
 	if resEm.Status != 200 {
 		t.Errorf("response status isn't 200")
 	}
-	if resEm.Headers["Content-type"] != "application/json" {
+	if resEm.Headers["Content-Type"] != "application/json" {
 		t.Errorf("wrong headers")
 	}
 }
@@ -137,7 +158,7 @@ func TestDo_Error(t *testing.T) {
 	if castErr.Status != 400 {
 		t.Errorf("expected status 400")
 	}
-	if castErr.Headers["Content-type"] != "text/plain" {
+	if castErr.Headers["Content-Type"] != "text/plain" {
 		t.Errorf("expected headers")
 	}
 	if castErr.Body != "Bad Request" {
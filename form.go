@@ -0,0 +1,450 @@
+package fetch
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const formContentType = "application/x-www-form-urlencoded"
+
+/*
+Form is a request body sent as application/x-www-form-urlencoded.
+Value is encoded with MarshalForm.
+e.g.
+
+	type Credentials struct {
+		GrantType string `json:"grant_type"`
+		Scope     []string
+	}
+	token, err := fetch.Post[Token]("/oauth/token", fetch.Form{Value: Credentials{GrantType: "client_credentials"}})
+*/
+type Form struct {
+	Value any
+}
+
+func (f Form) body() (*requestBody, error) {
+	s, err := MarshalForm(f.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &requestBody{
+		contentType: formContentType,
+		open: func() (io.Reader, error) {
+			return strings.NewReader(s), nil
+		},
+	}, nil
+}
+
+/*
+MarshalForm encodes a struct or a map into the URL encoded form.
+It follows the naming rules of Marshal: the first letter of the public struct fields is lowercased
+and empty fields are omitted unless the `json` tag is specified.
+Slices are encoded as repeated keys, nested structs and maps as bracketed keys, e.g. owner[name]=Lola,
+slices of structs with the index, e.g. tags[0][name]=cute. time.Time is formatted as RFC 3339.
+*/
+func MarshalForm(v any) (string, error) {
+	values, err := formValues(v)
+	if err != nil {
+		return "", err
+	}
+	return values.Encode(), nil
+}
+
+// UnmarshalForm decodes the URL encoded form into v, following the rules of MarshalForm.
+func UnmarshalForm(form string, v any) error {
+	values, err := url.ParseQuery(form)
+	if err != nil {
+		return err
+	}
+	return decodeForm(values, v)
+}
+
+func formValues(v any) (url.Values, error) {
+	switch u := v.(type) {
+	case url.Values:
+		return u, nil
+	case map[string][]string:
+		return u, nil
+	}
+	values := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct && rv.Kind() != reflect.Map {
+		return nil, fmt.Errorf("form requires a struct or a map, got %T", v)
+	}
+	err := encodeForm(values, "", rv)
+	return values, err
+}
+
+func encodeForm(values url.Values, key string, rv reflect.Value) error {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if s, ok, err := formScalar(rv); ok || err != nil {
+		if err != nil {
+			return err
+		}
+		values.Add(key, s)
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		for _, f := range formFields(rv.Type()) {
+			fv := rv.FieldByIndex(f.index)
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			if err := encodeForm(values, nestedKey(key, f.name), fv); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("form map key must be a string, got %s", rv.Type().Key())
+		}
+		keys := rv.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+		for _, k := range keys {
+			if err := encodeForm(values, nestedKey(key, k.String()), rv.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			elem := rv.Index(i)
+			elemKey := key
+			if isFormNested(elem) {
+				elemKey = key + "[" + strconv.Itoa(i) + "]"
+			}
+			if err := encodeForm(values, elemKey, elem); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("form doesn't support %s type", rv.Type())
+	}
+	return nil
+}
+
+func nestedKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "[" + name + "]"
+}
+
+// isFormNested checks if the value is encoded with its own keys.
+func isFormNested(rv reflect.Value) bool {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	if _, ok := rv.Interface().(encoding.TextMarshaler); ok {
+		return false
+	}
+	return rv.Kind() == reflect.Struct || rv.Kind() == reflect.Map
+}
+
+func formScalar(rv reflect.Value) (string, bool, error) {
+	if t, ok := rv.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339), true, nil
+	}
+	if tm, ok := rv.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), true, err
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()), true, nil
+	}
+	return "", false, nil
+}
+
+type formField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// formFields lists the public fields of the struct, including the fields of the embedded structs.
+func formFields(t reflect.Type) []formField {
+	var fields []formField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag, tagged := sf.Tag.Lookup("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			for _, f := range formFields(sf.Type) {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+			continue
+		}
+		if name == "" {
+			name = lowerFirst(sf.Name)
+		}
+		fields = append(fields, formField{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: !tagged || strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+	return fields
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+// formTree converts the bracketed keys into nested maps,
+// the leaves are the values of the key.
+func formTree(values url.Values) map[string]any {
+	root := map[string]any{}
+	for key, vals := range values {
+		path := formPath(key)
+		node := root
+		for i, p := range path {
+			if i == len(path)-1 {
+				prev, _ := node[p].([]string)
+				node[p] = append(prev, vals...)
+				break
+			}
+			child, ok := node[p].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				node[p] = child
+			}
+			node = child
+		}
+	}
+	return root
+}
+
+// formPath splits owner[tags][] into owner, tags.
+func formPath(key string) []string {
+	name, rest, found := strings.Cut(key, "[")
+	path := []string{name}
+	if !found {
+		return path
+	}
+	for _, p := range strings.Split(strings.TrimSuffix(rest, "]"), "][") {
+		if p != "" {
+			path = append(path, p)
+		}
+	}
+	return path
+}
+
+func decodeForm(values url.Values, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("form requires a non-nil pointer, got %T", v)
+	}
+	switch u := v.(type) {
+	case *url.Values:
+		*u = values
+		return nil
+	case *map[string][]string:
+		*u = values
+		return nil
+	}
+	return assignForm(rv.Elem(), formTree(values))
+}
+
+func assignForm(rv reflect.Value, node any) error {
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return assignForm(rv.Elem(), node)
+	}
+	if rv.Type() == reflectTypeFor[J]() || (rv.Kind() == reflect.Interface && rv.NumMethod() == 0) {
+		rv.Set(reflect.ValueOf(formJ(node)))
+		return nil
+	}
+	if tu, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(formLast(node)))
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		children, ok := node.(map[string]any)
+		if !ok {
+			return fmt.Errorf("form can't decode value into %s", rv.Type())
+		}
+		for _, f := range formFields(rv.Type()) {
+			child, ok := formChild(children, f.name)
+			if !ok {
+				continue
+			}
+			fv := rv.FieldByIndex(f.index)
+			if err := assignForm(fv, child); err != nil {
+				return fmt.Errorf("%s: %s", f.name, err)
+			}
+		}
+		return nil
+	case reflect.Map:
+		children, ok := node.(map[string]any)
+		if !ok || rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("form can't decode value into %s", rv.Type())
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+		for k, child := range children {
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err := assignForm(elem, child); err != nil {
+				return fmt.Errorf("%s: %s", k, err)
+			}
+			rv.SetMapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()), elem)
+		}
+		return nil
+	case reflect.Slice:
+		items := formItems(node)
+		s := reflect.MakeSlice(rv.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assignForm(s.Index(i), item); err != nil {
+				return fmt.Errorf("[%d]: %s", i, err)
+			}
+		}
+		rv.Set(s)
+		return nil
+	}
+	return setFormScalar(rv, formLast(node))
+}
+
+func formChild(children map[string]any, name string) (any, bool) {
+	if child, ok := children[name]; ok {
+		return child, true
+	}
+	for k, child := range children {
+		if strings.EqualFold(k, name) {
+			return child, true
+		}
+	}
+	return nil, false
+}
+
+// formItems lists the elements of the slice, either the repeated values or the indexed keys.
+func formItems(node any) []any {
+	switch u := node.(type) {
+	case []string:
+		items := make([]any, len(u))
+		for i, s := range u {
+			items[i] = []string{s}
+		}
+		return items
+	case map[string]any:
+		keys := make([]string, 0, len(u))
+		for k := range u {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b string) int {
+			ai, _ := strconv.Atoi(a)
+			bi, _ := strconv.Atoi(b)
+			return ai - bi
+		})
+		items := make([]any, len(keys))
+		for i, k := range keys {
+			items[i] = u[k]
+		}
+		return items
+	}
+	return nil
+}
+
+// formLast takes the last value of the key, the same as the headers are flattened.
+func formLast(node any) string {
+	if vals, ok := node.([]string); ok && len(vals) > 0 {
+		return vals[len(vals)-1]
+	}
+	return ""
+}
+
+func formJ(node any) J {
+	switch u := node.(type) {
+	case []string:
+		if len(u) == 1 {
+			return S(u[0])
+		}
+		a := make(A, len(u))
+		for i, s := range u {
+			a[i] = s
+		}
+		return a
+	case map[string]any:
+		m := make(M, len(u))
+		for k, child := range u {
+			m[k] = formJ(child).Elem()
+		}
+		return m
+	}
+	return jnil
+}
+
+func setFormScalar(rv reflect.Value, s string) error {
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(n)
+	default:
+		return fmt.Errorf("form can't decode value into %s", rv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package fetch
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type formOwner struct {
+	Name string
+}
+
+type formTag struct {
+	Name string
+}
+
+type formPet struct {
+	Name       string
+	GrantType  string `json:"grant_type"`
+	Age        int    `json:"age,omitempty"`
+	Vaccinated bool
+	Weight     float64
+	Tags       []string
+	Owner      *formOwner
+	Friends    []formTag
+	Born       time.Time
+	Secret     string `json:"-"`
+}
+
+func TestMarshalForm(t *testing.T) {
+	born := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	s, err := MarshalForm(formPet{
+		Name:    "Lola",
+		Tags:    []string{"cute", "small"},
+		Owner:   &formOwner{Name: "Jason"},
+		Friends: []formTag{{Name: "Max"}},
+		Born:    born,
+		Secret:  "hidden",
+		Weight:  4.5,
+	})
+	assert(t, err, nil)
+	want := url.Values{
+		"name":             {"Lola"},
+		"grant_type":       {""},
+		"tags":             {"cute", "small"},
+		"owner[name]":      {"Jason"},
+		"friends[0][name]": {"Max"},
+		"born":             {"2020-01-02T03:04:05Z"},
+		"weight":           {"4.5"},
+	}.Encode()
+	assert(t, s, want)
+
+	s, err = MarshalForm(map[string]any{"b": 1, "a": []int{1, 2}})
+	assert(t, err, nil)
+	assert(t, s, "a=1&a=2&b=1")
+
+	_, err = MarshalForm("string")
+	assertNotNil(t, err)
+}
+
+func TestUnmarshalForm(t *testing.T) {
+	var p formPet
+	err := UnmarshalForm("name=Lola&grant_type=code&age=3&vaccinated=true&weight=4.5&tags=cute&tags=small"+
+		"&owner[name]=Jason&friends[1][name]=Charles&friends[0][name]=Max&born=2020-01-02T03:04:05Z&secret=x", &p)
+	assert(t, err, nil)
+	if p.Name != "Lola" || p.GrantType != "code" || p.Age != 3 || !p.Vaccinated || p.Weight != 4.5 || p.Secret != "" {
+		t.Errorf("wrong scalars: %+v", p)
+	}
+	if len(p.Tags) != 2 || p.Tags[1] != "small" || p.Owner == nil || p.Owner.Name != "Jason" {
+		t.Errorf("wrong nested: %+v", p)
+	}
+	if len(p.Friends) != 2 || p.Friends[0].Name != "Max" || p.Friends[1].Name != "Charles" {
+		t.Errorf("wrong friends: %+v", p.Friends)
+	}
+	if !p.Born.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("wrong time: %s", p.Born)
+	}
+
+	var j J
+	err = UnmarshalForm("name=Lola&tags=a&tags=b&owner[name]=Jason", &j)
+	assert(t, err, nil)
+	assert(t, j.Q(".name").String(), "Lola")
+	assert(t, j.Q(".tags[1]").String(), "b")
+	assert(t, j.Q(".owner.name").String(), "Jason")
+
+	err = UnmarshalForm("age=old", &p)
+	assertNotNil(t, err)
+}
+
+func TestPostForm(t *testing.T) {
+	m := &Mock{}
+	m.On("POST", "/token").MatchHeader("Content-Type", "application/x-www-form-urlencoded").MatchBody("grant_type=client_credentials&name=Lola").
+		ReplyHeader("Content-Type", "application/x-www-form-urlencoded").Reply(200, "access_token=secret&expires_in=3600")
+	c := &Client{HttpClient: m.HttpClient()}
+
+	type Token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	token, err := PostWith[Token](c, "auth.io/token", Form{Value: formPet{Name: "Lola", GrantType: "client_credentials"}})
+	assert(t, err, nil)
+	assert(t, token.AccessToken, "secret")
+	assert(t, token.ExpiresIn, 3600)
+
+	res, err := PostWith[string](c, "auth.io/token", url.Values{"name": {"Lola"}, "grant_type": {"client_credentials"}})
+	assert(t, err, nil)
+	assert(t, res, "access_token=secret&expires_in=3600")
+}
+
+func TestToHandlerFunc_Form(t *testing.T) {
+	f := ToHandlerFunc(func(in Request[formPet]) (Empty, error) {
+		if in.Body.Name != "Lola" || len(in.Body.Tags) != 2 {
+			t.Errorf("wrong form body: %+v", in.Body)
+		}
+		return Empty{}, nil
+	})
+	mw := newMockWriter()
+	r, err := http.NewRequest("POST", "/pets", bytes.NewBufferString("name=Lola&tags=a&tags=b"))
+	assert(t, err, nil)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	f(mw, r)
+	assert(t, mw.status, 200)
+}
@@ -0,0 +1,57 @@
+package fetch
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHeader_MultipleValues(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "pets.io/pets").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		h := http.Header{}
+		h.Add("Link", `</pets?page=2>; rel="next"`)
+		h.Add("Link", `</pets?page=9>; rel="last"`)
+		h["X-Tags"] = req.Header.Values("X-Tags")
+		return mockResponse(req, 200, h, []byte("[]")), nil
+	})
+	m.On("GET", "pets.io/error").Reply(500, "").ReplyHeader("Warning", "1").ReplyHeader("Warning", "2")
+	c := &Client{HttpClient: m.HttpClient()}
+
+	res, err := GetWith[Response[[]Pet]](c, "pets.io/pets", Config{
+		Headers: map[string]string{"X-Tags": "old"},
+		Header:  http.Header{"X-Tags": {"cute", "small"}},
+	})
+	assert(t, err, nil)
+	assert(t, res.Headers["Link"], `</pets?page=9>; rel="last"`)
+	if !reflect.DeepEqual(res.Header.Values("Link"), []string{`</pets?page=2>; rel="next"`, `</pets?page=9>; rel="last"`}) {
+		t.Errorf("wrong Link values: %v", res.Header.Values("Link"))
+	}
+	if !reflect.DeepEqual(res.Header.Values("X-Tags"), []string{"cute", "small"}) {
+		t.Errorf("wrong X-Tags values: %v", res.Header.Values("X-Tags"))
+	}
+
+	_, err = GetWith[string](c, "pets.io/error")
+	if !reflect.DeepEqual(err.(*Error).Header.Values("Warning"), []string{"1", "2"}) {
+		t.Errorf("wrong Warning values: %v", err.(*Error).Header.Values("Warning"))
+	}
+}
+
+func TestToHandlerFunc_MultiValueHeaders(t *testing.T) {
+	f := ToHandlerFunc(func(in Request[Empty]) (Response[Empty], error) {
+		return Response[Empty]{
+			Status:  200,
+			Headers: map[string]string{"Vary": "Origin"},
+			Header:  http.Header{"Vary": in.Header.Values("X-Vary")},
+		}, nil
+	})
+	r, err := http.NewRequest("GET", "/", nil)
+	assert(t, err, nil)
+	r.Header.Add("X-Vary", "Accept")
+	r.Header.Add("X-Vary", "Accept-Encoding")
+	mw := newMockWriter()
+	f(mw, r)
+	if !reflect.DeepEqual(mw.Header().Values("Vary"), []string{"Accept", "Accept-Encoding"}) {
+		t.Errorf("wrong Vary values: %v", mw.Header().Values("Vary"))
+	}
+}
@@ -0,0 +1,41 @@
+package fetch
+
+import "net/http"
+
+// Next sends the request to the next interceptor in the chain,
+// the last one makes the actual HTTP request.
+type Next func(req *http.Request) (*http.Response, error)
+
+/*
+Interceptor wraps sending of the request. It can modify the request before passing it
+to next, inspect the raw response before its body is parsed, or return its own response
+without calling next at all. If the request is retried, the interceptors are called for every attempt.
+e.g.
+
+	logging := func(req *http.Request, next fetch.Next) (*http.Response, error) {
+		res, err := next(req)
+		if err == nil {
+			log.Println(req.Method, req.URL, res.StatusCode)
+		}
+		return res, err
+	}
+	fetch.SetInterceptors(logging)
+*/
+type Interceptor func(req *http.Request, next Next) (*http.Response, error)
+
+// SetInterceptors sets the interceptors of the default client.
+func SetInterceptors(interceptors ...Interceptor) {
+	defaultClient.Interceptors = interceptors
+}
+
+// chain builds Next calling the interceptors in order and then the last function.
+func chain(interceptors []Interceptor, last Next) Next {
+	next := last
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, n := interceptors[i], next
+		next = func(req *http.Request) (*http.Response, error) {
+			return interceptor(req, n)
+		}
+	}
+	return next
+}
@@ -0,0 +1,57 @@
+package fetch
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestInterceptors(t *testing.T) {
+	var order []string
+	named := func(name string) Interceptor {
+		return func(req *http.Request, next Next) (*http.Response, error) {
+			order = append(order, name)
+			req.Header.Set("X-"+name, "1")
+			return next(req)
+		}
+	}
+	c := &Client{HttpClient: testMock.HttpClient(), Interceptors: []Interceptor{named("first"), named("second")}}
+	res, err := PostWith[Response[M]](c, "echo.me", `{"key":"value"}`, Config{Interceptors: []Interceptor{
+		named("third"),
+		func(req *http.Request, next Next) (*http.Response, error) {
+			if req.Header.Get("X-first") == "" || req.Header.Get("X-third") == "" {
+				t.Errorf("headers of the previous interceptors are missing: %v", req.Header)
+			}
+			res, err := next(req)
+			if err == nil {
+				res.Header.Set("Intercepted", "yes")
+			}
+			return res, err
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Body["key"] != "value" {
+		t.Errorf("wrong response: %v", res.Body)
+	}
+	if res.Header.Get("Intercepted") != "yes" {
+		t.Errorf("interceptor should modify the response: %v", res.Header)
+	}
+	if strings.Join(order, ",") != "first,second,third" {
+		t.Errorf("wrong order: %v", order)
+	}
+}
+
+func TestInterceptors_ShortCircuit(t *testing.T) {
+	SetInterceptors(func(req *http.Request, next Next) (*http.Response, error) {
+		return &http.Response{StatusCode: 418, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("teapot"))}, nil
+	})
+	defer SetInterceptors()
+
+	_, err := Get[string]("unknown.host")
+	if err == nil || err.(*Error).Status != 418 || err.(*Error).Body != "teapot" {
+		t.Errorf("expected synthetic response, got=%v", err)
+	}
+}
@@ -103,6 +103,10 @@ func Unmarshal(data []byte, v any) error {
 	if err != nil {
 		return err
 	}
+	err = checkLimits(data)
+	if err != nil {
+		return err
+	}
 
 	d.init(data)
 	return d.unmarshal(v)
@@ -1,2 +1,2 @@
 rm -rf testdata/
-ls | grep '_test.go' | grep -v 'z_lower_case_test.go' | xargs rm
\ No newline at end of file
+ls | grep '_test.go' | grep -v '^z_' | xargs rm
\ No newline at end of file
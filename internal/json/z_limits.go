@@ -0,0 +1,91 @@
+package json
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Limits of the JSON accepted by Unmarshal. Zero means no limit.
+type Limits struct {
+	// Maximum nesting depth of objects and arrays.
+	MaxDepth int
+	// Maximum length of a string in bytes as it's encoded, including object keys.
+	MaxStringLength int
+	// Maximum number of elements in an array.
+	MaxArrayLength int
+}
+
+// A LimitError is returned by Unmarshal if the JSON exceeds Limits.
+type LimitError struct {
+	msg    string
+	Offset int64 // error occurred after reading Offset bytes
+}
+
+func (e *LimitError) Error() string { return e.msg }
+
+var limits atomic.Pointer[Limits]
+
+// SetLimits sets the limits checked by Unmarshal.
+func SetLimits(l Limits) {
+	limits.Store(&l)
+}
+
+// checkLimits checks the valid JSON against the limits.
+func checkLimits(data []byte) error {
+	l := limits.Load()
+	if l == nil || *l == (Limits{}) {
+		return nil
+	}
+	// number of elements of the open arrays, -1 for objects.
+	var stack []int
+	// the next value is an element of the array on top of the stack.
+	element := false
+	inString, escaped := false, false
+	stringStart := 0
+	for i, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if l.MaxStringLength > 0 && i-stringStart-1 > l.MaxStringLength {
+					return &LimitError{fmt.Sprintf("json: string exceeds %d bytes", l.MaxStringLength), int64(i)}
+				}
+			}
+			continue
+		}
+		if isSpace(c) {
+			continue
+		}
+		if element && c != ']' {
+			element = false
+			stack[len(stack)-1]++
+			if l.MaxArrayLength > 0 && stack[len(stack)-1] > l.MaxArrayLength {
+				return &LimitError{fmt.Sprintf("json: array exceeds %d elements", l.MaxArrayLength), int64(i)}
+			}
+		}
+		switch c {
+		case '"':
+			inString, stringStart = true, i
+		case '[', '{':
+			if l.MaxDepth > 0 && len(stack) >= l.MaxDepth {
+				return &LimitError{fmt.Sprintf("json: nesting exceeds depth %d", l.MaxDepth), int64(i)}
+			}
+			if c == '[' {
+				stack = append(stack, 0)
+				element = true
+			} else {
+				stack = append(stack, -1)
+			}
+		case ']', '}':
+			stack = stack[:len(stack)-1]
+			element = false
+		case ',':
+			element = stack[len(stack)-1] >= 0
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	defer SetLimits(Limits{})
+	SetLimits(Limits{MaxDepth: 2, MaxStringLength: 5, MaxArrayLength: 3})
+
+	valid := []string{
+		`{"a":[1,2,3]}`,
+		`[[1, 2, 3], ["abcde"], []]`,
+		`{"a":{"b":1},"c":"a\"bc"}`,
+		`[ ]`,
+	}
+	for _, s := range valid {
+		var v any
+		if err := Unmarshal([]byte(s), &v); err != nil {
+			t.Errorf("%s: unexpected error %v", s, err)
+		}
+	}
+
+	invalid := map[string]string{
+		`[[[1]]]`:              "json: nesting exceeds depth 2",
+		`{"a":{"b":{}}}`:       "json: nesting exceeds depth 2",
+		`"abcdef"`:             "json: string exceeds 5 bytes",
+		`{"abcdef":1}`:         "json: string exceeds 5 bytes",
+		`[1,2,3,4]`:            "json: array exceeds 3 elements",
+		`[[],{},"a",[1,2]]`:    "json: array exceeds 3 elements",
+		`[1,[1,2,3,"abcdef"]]`: "json: array exceeds 3 elements",
+	}
+	for s, msg := range invalid {
+		var v any
+		err := Unmarshal([]byte(s), &v)
+		if _, ok := err.(*LimitError); !ok || err.Error() != msg {
+			t.Errorf("%s: expected %q, got %v", s, msg, err)
+		}
+	}
+
+	SetLimits(Limits{})
+	var v any
+	if err := Unmarshal([]byte("["+strings.Repeat("1,", 100)+"1]"), &v); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
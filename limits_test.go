@@ -0,0 +1,63 @@
+package fetch
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestConfig_MaxResponseSize(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "pets.io/pets").Reply(200, `[{"name":"Lola"},{"name":"Max"}]`)
+	m.On("GET", "pets.io/error").Reply(500, strings.Repeat("a", 100))
+	c := &Client{HttpClient: m.HttpClient()}
+
+	_, err := GetWith[[]Pet](c, "pets.io/pets", Config{MaxResponseSize: 10})
+	var sizeErr *SizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Limit != 10 {
+		t.Fatalf("expected SizeError, got %v", err)
+	}
+	assert(t, err.(*Error).Status, 200)
+
+	pets, err := GetWith[[]Pet](c, "pets.io/pets", Config{MaxResponseSize: 32})
+	assert(t, err, nil)
+	assert(t, len(pets), 2)
+
+	_, err = GetWith[string](c, "pets.io/error", Config{MaxResponseSize: 10})
+	if !errors.As(err, &sizeErr) {
+		t.Fatalf("expected SizeError, got %v", err)
+	}
+}
+
+func TestHandlerConfig_MaxRequestSize(t *testing.T) {
+	defer SetHandlerConfig(HandlerConfig{})
+	SetHandlerConfig(HandlerConfig{MaxRequestSize: 16})
+	f := ToHandlerFunc(func(in Pet) (Pet, error) {
+		return in, nil
+	})
+
+	r, err := http.NewRequest("POST", "/pets", strings.NewReader(`{"name":"Lola"}`))
+	assert(t, err, nil)
+	mw := newMockWriter()
+	f(mw, r)
+	assert(t, mw.status, 200)
+
+	r, err = http.NewRequest("POST", "/pets", strings.NewReader(`{"name":"Charles"}`))
+	assert(t, err, nil)
+	mw = newMockWriter()
+	f(mw, r)
+	assert(t, mw.status, 413)
+}
+
+func TestSetJSONLimits(t *testing.T) {
+	defer SetJSONLimits(JSONLimits{})
+	SetJSONLimits(JSONLimits{MaxArrayLength: 2})
+
+	_, err := Unmarshal[[]int]("[1,2,3]")
+	assertNotNil(t, err)
+	m := &Mock{}
+	m.On("GET", "pets.io/pets").Reply(200, `[{"name":"Lola"},{"name":"Max"},{"name":"Charles"}]`)
+	_, err = GetWith[[]Pet](&Client{HttpClient: m.HttpClient()}, "pets.io/pets")
+	assertNotNil(t, err)
+}
@@ -0,0 +1,217 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LogOptions configure the records of the requests written to slog.Logger.
+// Successful requests are logged at Info level, 4xx responses at Warn and the rest of the failures at Error.
+type LogOptions struct {
+	// Headers adds the request and response headers to the records.
+	Headers bool
+	// Redact lists the headers whose values are replaced with REDACTED.
+	// Defaults to Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	Redact []string
+	// BodySize is the number of bytes of the request and response bodies added to the records.
+	// Zero means no bodies. The bodies of the requests which can't be read twice aren't logged.
+	BodySize int
+	// RedactBody returns the body to log instead of the logged part of the body, e.g. with the passwords masked.
+	// It's called with the Content-Type of the body and its first BodySize bytes.
+	RedactBody func(contentType string, body []byte) []byte
+}
+
+// SetLogger sets the logger of the default client.
+func SetLogger(l *slog.Logger) {
+	defaultClient.Logger = l
+}
+
+func (o LogOptions) headers(h http.Header) slog.Attr {
+	redact := o.Redact
+	if redact == nil {
+		redact = defaultRedactedHeaders
+	}
+	return slog.Any("headers", redactHeaders(h, redact))
+}
+
+// body returns the logged part of the captured body, which has a byte more than BodySize if it's longer.
+func (o LogOptions) body(contentType string, b []byte) string {
+	truncated := len(b) > o.BodySize
+	if truncated {
+		b = b[:o.BodySize]
+	}
+	if o.RedactBody != nil {
+		b = o.RedactBody(contentType, b)
+	}
+	if truncated {
+		return string(b) + "..."
+	}
+	return string(b)
+}
+
+func logLevel(status int, err error) slog.Level {
+	switch {
+	case err != nil || status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// logURL is the URL without the query, which may have secrets.
+func logURL(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery, u.ForceQuery, u.User = "", false, nil
+	return u.String()
+}
+
+// logRequest is the first interceptor logging every attempt
+// once the response body is closed or the request fails.
+func (c *Client) logRequest(req *http.Request, next Next) (*http.Response, error) {
+	start := time.Now()
+	opts := c.LogOptions
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", logURL(req)),
+		slog.String("route", Route(req)),
+	}
+	ex := exchangeOf(req)
+	if ex != nil {
+		attrs = append(attrs, slog.Int("attempt", ex.attempt))
+	}
+	if req.ContentLength > 0 {
+		attrs = append(attrs, slog.Int64("request_bytes", req.ContentLength))
+	}
+	if opts.Headers {
+		attrs = append(attrs, slog.Group("request", opts.headers(req.Header)))
+	}
+	if opts.BodySize > 0 && req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+		if body, err := req.GetBody(); err == nil {
+			b, _ := io.ReadAll(io.LimitReader(body, int64(opts.BodySize)+1))
+			body.Close()
+			attrs = append(attrs, slog.String("request_body", opts.body(req.Header.Get("Content-Type"), b)))
+		}
+	}
+
+	res, err := next(req)
+	if err != nil {
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)), slog.String("error", err.Error()))
+		c.Logger.LogAttrs(req.Context(), slog.LevelError, "fetch request", attrs...)
+		return res, err
+	}
+	attrs = append(attrs, slog.Int("status", res.StatusCode))
+	if ex != nil && ex.cached {
+		attrs = append(attrs, slog.Bool("cached", true))
+	}
+	if opts.Headers {
+		attrs = append(attrs, slog.Group("response", opts.headers(res.Header)))
+	}
+	ctx := context.WithoutCancel(req.Context())
+	res.Body = &observedBody{ReadCloser: res.Body, capture: opts.BodySize, onClose: func(read int64, captured []byte) {
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)), slog.Int64("response_bytes", read))
+		if opts.BodySize > 0 {
+			attrs = append(attrs, slog.String("response_body", opts.body(res.Header.Get("Content-Type"), captured)))
+		}
+		c.Logger.LogAttrs(ctx, logLevel(res.StatusCode, nil), "fetch request", attrs...)
+	}}
+	return res, nil
+}
+
+// observedBody counts the read bytes, capturing the first ones, and calls onClose once.
+type observedBody struct {
+	io.ReadCloser
+	read     int64
+	capture  int
+	captured []byte
+	once     sync.Once
+	onClose  func(read int64, captured []byte)
+}
+
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if rest := b.capture + 1 - len(b.captured); b.capture > 0 && rest > 0 {
+		b.captured = append(b.captured, p[:min(n, rest)]...)
+	}
+	return n, err
+}
+
+func (b *observedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.onClose(b.read, b.captured)
+	})
+	return err
+}
+
+// observedWriter records the status and the size of the handler response.
+type observedWriter struct {
+	http.ResponseWriter
+	status   int
+	written  int64
+	capture  int
+	captured []byte
+}
+
+func (w *observedWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *observedWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	if rest := w.capture + 1 - len(w.captured); w.capture > 0 && rest > 0 {
+		w.captured = append(w.captured, p[:min(n, rest)]...)
+	}
+	return n, err
+}
+
+func (w *observedWriter) Flush() {
+	flush(w.ResponseWriter)
+}
+
+func (w *observedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// logHandled logs the request processed by ToHandlerFunc.
+func (cfg HandlerConfig) logHandled(w *observedWriter, r *http.Request, start time.Time, reqBody []byte, err error) {
+	opts := cfg.LogOptions
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("url", logURL(r)),
+		slog.String("route", routePattern(r)),
+		slog.Int("status", w.status),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int64("response_bytes", w.written),
+	}
+	if r.ContentLength > 0 {
+		attrs = append(attrs, slog.Int64("request_bytes", r.ContentLength))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if opts.Headers {
+		attrs = append(attrs, slog.Group("request", opts.headers(r.Header)), slog.Group("response", opts.headers(w.Header())))
+	}
+	if opts.BodySize > 0 {
+		attrs = append(attrs, slog.String("request_body", opts.body(r.Header.Get("Content-Type"), reqBody)),
+			slog.String("response_body", opts.body(w.Header().Get("Content-Type"), w.captured)))
+	}
+	level := logLevel(w.status, nil)
+	if err != nil && w.status < 400 {
+		level = slog.LevelError
+	}
+	cfg.Logger.LogAttrs(r.Context(), level, "fetch handler", attrs...)
+}
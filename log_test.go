@@ -0,0 +1,130 @@
+package fetch
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger() (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey || a.Key == "duration" {
+			return slog.Attr{}
+		}
+		return a
+	}})
+	return slog.New(h), &buf
+}
+
+func TestClient_Logger(t *testing.T) {
+	m := &Mock{}
+	m.On("POST", "pets.io/pets/{id}").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("X-Retry") == "" {
+			req.Header.Set("X-Retry", "1")
+			return mockResponse(req, 503, nil, []byte("unavailable")), nil
+		}
+		return mockResponse(req, 201, http.Header{"Set-Cookie": {"session=abc"}}, []byte(`{"name":"Lola the cat"}`)), nil
+	})
+	logger, buf := newTestLogger()
+	c := &Client{HttpClient: m.HttpClient(), Logger: logger, LogOptions: LogOptions{Headers: true, BodySize: 10}}
+
+	_, err := PostWith[Pet](c, "pets.io/pets/{id}?token=secret", Pet{Name: "Lola"}, Config{
+		PathParams: map[string]any{"id": 1},
+		Headers:    map[string]string{"Authorization": "Bearer secret"},
+		Retry:      Retry{Attempts: 2, Backoff: time.Millisecond},
+	})
+	assert(t, err, nil)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert(t, len(lines), 2)
+	assert(t, lines[0], `level=ERROR msg="fetch request" method=POST url=https://pets.io/pets/1 route=pets.io/pets/{id} attempt=1 request_bytes=15 request.headers="map[Authorization:[REDACTED] Content-Type:[application/json]]" request_body="{\"name\":\"L..." status=503 response.headers=map[] response_bytes=11 response_body=unavailabl...`)
+	assert(t, lines[1], `level=INFO msg="fetch request" method=POST url=https://pets.io/pets/1 route=pets.io/pets/{id} attempt=2 request_bytes=15 request.headers="map[Authorization:[REDACTED] Content-Type:[application/json] X-Retry:[1]]" request_body="{\"name\":\"L..." status=201 response.headers=map[Set-Cookie:[REDACTED]] response_bytes=23 response_body="{\"name\":\"L..."`)
+
+	buf.Reset()
+	m.FailUnmatched = true
+	_, err = GetWith[Pet](c, "pets.io/unknown")
+	assertNotNil(t, err)
+	if !strings.HasPrefix(buf.String(), `level=ERROR msg="fetch request" method=GET url=https://pets.io/unknown route=pets.io/unknown attempt=1 request.headers=`) ||
+		!strings.Contains(buf.String(), `fetch.Mock: no route for GET https://pets.io/unknown`) {
+		t.Errorf("wrong log: %s", buf.String())
+	}
+}
+
+func TestClient_LoggerRedactBody(t *testing.T) {
+	m := &Mock{}
+	m.On("POST", "pets.io/login").ReplyHeader("Content-Type", "application/json").Reply(200, `{"token":"secret"}`)
+	logger, buf := newTestLogger()
+	redact := func(contentType string, body []byte) []byte {
+		return []byte(contentType + " " + strings.ReplaceAll(string(body), "secret", "***"))
+	}
+	c := &Client{HttpClient: m.HttpClient(), Logger: logger, LogOptions: LogOptions{BodySize: 100, RedactBody: redact}}
+
+	_, err := PostWith[M](c, "pets.io/login", M{"password": "secret"})
+	assert(t, err, nil)
+	if !strings.Contains(buf.String(), `request_body="application/json {\"password\":\"***\"}"`) ||
+		!strings.Contains(buf.String(), `response_body="application/json {\"token\":\"***\"}"`) {
+		t.Errorf("bodies should be redacted: %s", buf.String())
+	}
+}
+
+func TestHandlerConfig_Logger(t *testing.T) {
+	logger, buf := newTestLogger()
+	defer SetHandlerConfig(HandlerConfig{})
+	SetHandlerConfig(HandlerConfig{Logger: logger, LogOptions: LogOptions{BodySize: 20}})
+	f := ToHandlerFunc(func(in Pet) (Pet, error) {
+		if in.Name == "" {
+			return Pet{}, &Error{Msg: "name is required", Status: 422}
+		}
+		return in, nil
+	})
+
+	r, err := http.NewRequest("POST", "/pets", strings.NewReader(`{"name":"Lola"}`))
+	assert(t, err, nil)
+	f(newMockWriter(), r)
+	r, err = http.NewRequest("POST", "/pets", strings.NewReader(`{}`))
+	assert(t, err, nil)
+	f(newMockWriter(), r)
+	r, err = http.NewRequest("POST", "/pets", strings.NewReader(`{`))
+	assert(t, err, nil)
+	f(newMockWriter(), r)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert(t, len(lines), 4)
+	assert(t, lines[0], `level=INFO msg="fetch handler" method=POST url=/pets route="" status=200 response_bytes=15 request_bytes=15 request_body="{\"name\":\"Lola\"}" response_body="{\"name\":\"Lola\"}"`)
+	assert(t, lines[1], `level=WARN msg="fetch handler" method=POST url=/pets route="" status=422 response_bytes=28 request_bytes=2 error="name is required" request_body={} response_body="{\"error\":\"name is re..."`)
+	// the error hook logs with the handler logger too.
+	if !strings.HasPrefix(lines[2], `level=ERROR msg="fetch.Handle failed to respond" error="parse request body:`) {
+		t.Errorf("wrong log: %s", lines[2])
+	}
+	if !strings.HasPrefix(lines[3], `level=WARN msg="fetch handler" method=POST url=/pets route="" status=400`) {
+		t.Errorf("wrong log: %s", lines[3])
+	}
+}
+
+func TestClient_ErrorHookLogger(t *testing.T) {
+	logger, buf := newTestLogger()
+	c := &Client{Logger: logger}
+	c.errorHook(errors.New("resource leak"))
+	assert(t, buf.String(), "level=ERROR msg=\"resource leak\"\n")
+}
+
+func TestHandlerConfig_LoggerRedactBody(t *testing.T) {
+	logger, buf := newTestLogger()
+	defer SetHandlerConfig(HandlerConfig{})
+	SetHandlerConfig(HandlerConfig{Logger: logger, LogOptions: LogOptions{BodySize: 100, RedactBody: func(contentType string, body []byte) []byte {
+		return []byte(strings.ReplaceAll(string(body), "secret", "***"))
+	}}})
+	f := ToHandlerFunc(func(in M) (M, error) {
+		return M{"token": "secret"}, nil
+	})
+	r, err := http.NewRequest("POST", "/login", strings.NewReader(`{"password":"secret"}`))
+	assert(t, err, nil)
+	f(newMockWriter(), r)
+	if strings.Contains(buf.String(), "secret") || !strings.Contains(buf.String(), `request_body="{\"password\":\"***\"}"`) {
+		t.Errorf("bodies should be redacted: %s", buf.String())
+	}
+}
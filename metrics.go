@@ -0,0 +1,199 @@
+package fetch
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsEvent describes the request sent by Client or handled by ToHandlerFunc.
+// The start events have only Server, Method and Route set.
+type MetricsEvent struct {
+	// Server is true for the requests handled by ToHandlerFunc.
+	Server bool
+	Method string
+	// Route is the URL template of the client request, see fetch.Route,
+	// or the ServeMux pattern of the handled request, empty before go1.22.
+	Route string
+	// Status of the response, zero if the request failed without it.
+	Status int
+	// StatusClass is 2xx, 3xx, 4xx, 5xx or error if there is no response.
+	StatusClass   string
+	Duration      time.Duration
+	RequestBytes  int64
+	ResponseBytes int64
+	Err           error
+}
+
+/*
+Metrics observes the requests of Client and the handlers of ToHandlerFunc.
+The client request ends once its response body is closed, including the retries.
+e.g.
+
+	metrics := fetch.NewHistogram()
+	metrics.Publish("fetch")
+	petstore := &fetch.Client{Metrics: metrics}
+	fetch.SetHandlerConfig(fetch.HandlerConfig{Metrics: metrics})
+*/
+type Metrics interface {
+	RequestStart(e MetricsEvent)
+	RequestEnd(e MetricsEvent)
+}
+
+func statusClass(status int) string {
+	if status == 0 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", firstDigit(status))
+}
+
+// observeRequest reports the end of the request, which is deferred until the response body is closed.
+func (c *Client) observeRequest(req *http.Request, res *http.Response, err error, start time.Time) {
+	e := MetricsEvent{Method: req.Method, Route: Route(req)}
+	if req.ContentLength > 0 {
+		e.RequestBytes = req.ContentLength
+	}
+	if err != nil {
+		if ferr, ok := err.(*Error); ok {
+			e.Status = ferr.Status
+		}
+		e.StatusClass, e.Duration, e.Err = statusClass(e.Status), time.Since(start), err
+		c.Metrics.RequestEnd(e)
+		return
+	}
+	e.Status, e.StatusClass = res.StatusCode, statusClass(res.StatusCode)
+	res.Body = &observedBody{ReadCloser: res.Body, onClose: func(read int64, _ []byte) {
+		e.Duration, e.ResponseBytes = time.Since(start), read
+		c.Metrics.RequestEnd(e)
+	}}
+}
+
+var defaultBuckets = []time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// Histogram is Metrics keeping the histograms of the request durations in memory,
+// separately for every side, method, route and status class.
+type Histogram struct {
+	buckets  []time.Duration
+	mu       sync.Mutex
+	series   map[seriesKey]*HistogramSeries
+	inFlight [2]int64
+}
+
+type seriesKey struct {
+	server      bool
+	method      string
+	route       string
+	statusClass string
+}
+
+// HistogramSeries is the histogram of the requests with the same side, method, route and status class.
+type HistogramSeries struct {
+	Server      bool   `json:"server"`
+	Method      string `json:"method"`
+	Route       string `json:"route"`
+	StatusClass string `json:"statusClass"`
+	Count       uint64 `json:"count"`
+	// Sum of the durations.
+	Sum time.Duration `json:"sum"`
+	// Counts[i] is the number of the requests with the duration up to Buckets[i],
+	// the last one counts the longer requests.
+	Counts        []uint64 `json:"counts"`
+	RequestBytes  int64    `json:"requestBytes"`
+	ResponseBytes int64    `json:"responseBytes"`
+}
+
+// NewHistogram creates Histogram with the upper bounds of the buckets.
+// Defaults to 5ms, 10ms, 25ms, 50ms, 100ms, 250ms, 500ms, 1s, 2.5s, 5s and 10s.
+func NewHistogram(buckets ...time.Duration) *Histogram {
+	if len(buckets) == 0 {
+		buckets = defaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Histogram{buckets: buckets, series: map[seriesKey]*HistogramSeries{}}
+}
+
+func (h *Histogram) RequestStart(e MetricsEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight[side(e.Server)]++
+}
+
+func (h *Histogram) RequestEnd(e MetricsEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight[side(e.Server)]--
+	key := seriesKey{server: e.Server, method: e.Method, route: e.Route, statusClass: e.StatusClass}
+	s, ok := h.series[key]
+	if !ok {
+		s = &HistogramSeries{Server: e.Server, Method: e.Method, Route: e.Route, StatusClass: e.StatusClass, Counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+	}
+	s.Count++
+	s.Sum += e.Duration
+	i, _ := slices.BinarySearch(h.buckets, e.Duration)
+	s.Counts[i]++
+	s.RequestBytes += e.RequestBytes
+	s.ResponseBytes += e.ResponseBytes
+}
+
+func side(server bool) int {
+	if server {
+		return 1
+	}
+	return 0
+}
+
+// Buckets returns the upper bounds of the buckets.
+func (h *Histogram) Buckets() []time.Duration {
+	return slices.Clone(h.buckets)
+}
+
+// InFlight returns the number of the started requests which haven't ended yet.
+func (h *Histogram) InFlight(server bool) int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.inFlight[side(server)]
+}
+
+// Series returns the copy of the histograms sorted by side, method, route and status class.
+func (h *Histogram) Series() []HistogramSeries {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	series := make([]HistogramSeries, 0, len(h.series))
+	for _, s := range h.series {
+		c := *s
+		c.Counts = slices.Clone(s.Counts)
+		series = append(series, c)
+	}
+	slices.SortFunc(series, func(a, b HistogramSeries) int {
+		if a.Server != b.Server {
+			if a.Server {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.Method+" "+a.Route+" "+a.StatusClass, b.Method+" "+b.Route+" "+b.StatusClass)
+	})
+	return series
+}
+
+// Publish exports the histogram as the expvar variable with the name, served at /debug/vars.
+// It panics if the name is already registered, like expvar.Publish.
+func (h *Histogram) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return map[string]any{
+			"buckets":  h.Buckets(),
+			"inFlight": map[string]int64{"client": h.InFlight(false), "server": h.InFlight(true)},
+			"series":   h.Series(),
+		}
+	}))
+}
@@ -0,0 +1,117 @@
+package fetch
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Metrics(t *testing.T) {
+	m := &Mock{FailUnmatched: true}
+	m.On("POST", "pets.io/pets/{id}").Reply(200, `{"name":"Lola the cat"}`)
+	h := NewHistogram()
+	c := &Client{HttpClient: m.HttpClient(), Metrics: h}
+
+	_, err := PostWith[Pet](c, "pets.io/pets/{id}", Pet{Name: "Lola"}, Config{PathParams: map[string]any{"id": 1}})
+	assert(t, err, nil)
+	_, err = GetWith[Pet](c, "pets.io/unknown")
+	assertNotNil(t, err)
+
+	assert(t, h.InFlight(false), int64(0))
+	series := h.Series()
+	assert(t, len(series), 2)
+	assert(t, series[0].Method, "GET")
+	assert(t, series[0].Route, "pets.io/unknown")
+	assert(t, series[0].StatusClass, "error")
+	assert(t, series[1].Method, "POST")
+	assert(t, series[1].Route, "pets.io/pets/{id}")
+	assert(t, series[1].StatusClass, "2xx")
+	assert(t, series[1].Count, uint64(1))
+	assert(t, series[1].RequestBytes, int64(15))
+	assert(t, series[1].ResponseBytes, int64(23))
+	assert(t, len(series[1].Counts), len(defaultBuckets)+1)
+}
+
+func TestClient_MetricsStream(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "pets.io/pets").Reply(200, `[{"name":"Lola"}]`)
+	h := NewHistogram()
+	c := &Client{HttpClient: m.HttpClient(), Metrics: h}
+
+	res, err := GetWith[io.ReadCloser](c, "pets.io/pets")
+	assert(t, err, nil)
+	assert(t, h.InFlight(false), int64(1))
+	res.Close()
+	assert(t, h.InFlight(false), int64(0))
+	assert(t, h.Series()[0].ResponseBytes, int64(0))
+}
+
+func TestHandlerConfig_Metrics(t *testing.T) {
+	h := NewHistogram()
+	defer SetHandlerConfig(HandlerConfig{})
+	SetHandlerConfig(HandlerConfig{Metrics: h})
+	f := ToHandlerFunc(func(in Pet) (Pet, error) {
+		if in.Name == "" {
+			return Pet{}, &Error{Msg: "name is required", Status: 422}
+		}
+		return in, nil
+	})
+
+	for _, body := range []string{`{"name":"Lola"}`, `{"name":"Luna"}`, `{}`} {
+		r, err := http.NewRequest("POST", "/pets", strings.NewReader(body))
+		assert(t, err, nil)
+		f(newMockWriter(), r)
+	}
+
+	assert(t, h.InFlight(true), int64(0))
+	series := h.Series()
+	assert(t, len(series), 2)
+	assert(t, series[0].Server, true)
+	assert(t, series[0].StatusClass, "2xx")
+	assert(t, series[0].Count, uint64(2))
+	assert(t, series[0].RequestBytes, int64(30))
+	assert(t, series[0].ResponseBytes, int64(30))
+	assert(t, series[1].StatusClass, "4xx")
+	assert(t, series[1].Count, uint64(1))
+}
+
+func TestHistogram_Buckets(t *testing.T) {
+	h := NewHistogram(time.Second, 10*time.Millisecond)
+	assert(t, fmt.Sprint(h.Buckets()), "[10ms 1s]")
+	for _, d := range []time.Duration{time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, time.Minute} {
+		h.RequestStart(MetricsEvent{Method: "GET"})
+		h.RequestEnd(MetricsEvent{Method: "GET", StatusClass: "2xx", Duration: d})
+	}
+	s := h.Series()[0]
+	assert(t, fmt.Sprint(s.Counts), "[2 1 1]")
+	assert(t, s.Sum, time.Minute+31*time.Millisecond)
+}
+
+// published makes the expvar names unique, they can't be registered again with -count.
+var published atomic.Int32
+
+func TestHistogram_Publish(t *testing.T) {
+	name := fmt.Sprintf("%s_%d", t.Name(), published.Add(1))
+	h := NewHistogram()
+	h.Publish(name)
+	h.RequestStart(MetricsEvent{Server: true, Method: "GET", Route: "/pets"})
+	h.RequestEnd(MetricsEvent{Server: true, Method: "GET", Route: "/pets", Status: 200, StatusClass: "2xx", Duration: time.Millisecond})
+
+	v := expvar.Get(name)
+	assertNotNil(t, v)
+	var metrics struct {
+		InFlight map[string]int64  `json:"inFlight"`
+		Series   []HistogramSeries `json:"series"`
+	}
+	assert(t, json.Unmarshal([]byte(v.String()), &metrics), nil)
+	assert(t, metrics.InFlight["server"], int64(0))
+	assert(t, len(metrics.Series), 1)
+	assert(t, metrics.Series[0].Route, "/pets")
+	assert(t, metrics.Series[0].Count, uint64(1))
+}
@@ -2,60 +2,297 @@ package fetch
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"strings"
+	"sync"
 )
 
-var mock bool
+/*
+Mock is a registry of fake HTTP responses for tests. It implements http.RoundTripper
+and never opens a socket.
+e.g.
 
-type mockResponse struct {
-	Status  int
-	Headers map[string][]string
-	Body    string
+	m := &fetch.Mock{FailUnmatched: true}
+	m.On("GET", "https://petstore.swagger.io/v2/pet/{id}").Reply(200, Pet{Name: "Lola"})
+	fetch.SetHttpClient(m.HttpClient())
+	defer m.Assert(t)
+
+	pet, err := fetch.Get[Pet]("https://petstore.swagger.io/v2/pet/1")
+
+A request is served by the first registered route matching it.
+*/
+type Mock struct {
+	// FailUnmatched makes the requests matching no route fail with an error.
+	// Otherwise, they get 404 response.
+	FailUnmatched bool
+
+	mu        sync.Mutex
+	routes    []*MockRoute
+	unmatched []string
 }
 
-type bufferClose struct {
-	b *bytes.Buffer
+// MockRoute is a mocked response for the requests matching its method, URL and other conditions.
+type MockRoute struct {
+	mock    *Mock
+	method  string
+	pattern string
+	match   func(req *http.Request) bool
+	conds   []func(req *http.Request, body []byte) bool
+	respond func(req *http.Request) (*http.Response, error)
+	status  int
+	header  http.Header
+	body    []byte
+	times   int
+	calls   int
 }
 
-func newBC(str string) *bufferClose {
-	return &bufferClose{b: bytes.NewBuffer([]byte(str))}
+// On registers the route for the method and URL pattern. Empty method or "*" matches any method.
+// The pattern is matched against the URL without query, unless the pattern has one.
+// If the pattern doesn't have a protocol it is matched against host and path,
+// or only path if it starts with a slash.
+// The pattern supports wildcards of the path segments like /pets/{id}
+// and matches by prefix if it ends with *.
+func (m *Mock) On(method, pattern string) *MockRoute {
+	return m.add(method, pattern, func(req *http.Request) bool {
+		return matchPattern(pattern, mockURL(pattern, req))
+	})
 }
 
-func (bc *bufferClose) Read(p []byte) (n int, err error) {
-	return bc.b.Read(p)
+// OnRegexp registers the route for the method and the URL matching the regular expression.
+// The regular expression is matched against the full URL.
+func (m *Mock) OnRegexp(method string, re *regexp.Regexp) *MockRoute {
+	return m.add(method, re.String(), func(req *http.Request) bool {
+		return re.MatchString(req.URL.String())
+	})
 }
 
-func (bc *bufferClose) Close() error {
-	return nil
+func (m *Mock) add(method, pattern string, match func(req *http.Request) bool) *MockRoute {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := &MockRoute{mock: m, method: strings.ToUpper(method), pattern: pattern, match: match, status: 200, header: http.Header{}, times: -1}
+	m.routes = append(m.routes, r)
+	return r
 }
 
-func mockDNS(url string, req *http.Request) *mockResponse {
-	switch url {
-	case "key.value":
-		return &mockResponse{Status: 200, Headers: map[string][]string{"Content-type": {"application/json"}}, Body: `{"key":"value"}`}
-	case "array.int":
-		return &mockResponse{Status: 200, Body: `[1, 2, 3]`}
-	case "my.ip":
-		return &mockResponse{Status: 200, Headers: map[string][]string{"Content-type": {"text/plain"}}, Body: `8.8.8.8`}
-	case "400.error":
-		return &mockResponse{Status: 400, Headers: map[string][]string{"Content-type": {"text/plain"}}, Body: `Bad Request`}
-	case "echo.me":
-		body, err := io.ReadAll(req.Body)
-		if err != nil {
-			panic(err)
+// MatchHeader requires the request to have the header with the value.
+func (r *MockRoute) MatchHeader(key, value string) *MockRoute {
+	r.conds = append(r.conds, func(req *http.Request, _ []byte) bool {
+		for _, v := range req.Header.Values(key) {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	})
+	return r
+}
+
+// MatchBody requires the request body to be equal to the body.
+// If both are valid JSON, they are compared ignoring formatting and the order of keys.
+func (r *MockRoute) MatchBody(body string) *MockRoute {
+	want := Parse(body)
+	r.conds = append(r.conds, func(_ *http.Request, got []byte) bool {
+		if string(got) == body {
+			return true
 		}
-		return &mockResponse{Status: 200, Body: string(body)}
+		return !want.IsNil() && want.String() == Parse(string(got)).String()
+	})
+	return r
+}
+
+// Reply sets the response status and body. String and []byte bodies are sent as is,
+// other values are marshaled into JSON.
+func (r *MockRoute) Reply(status int, body any) *MockRoute {
+	r.status = status
+	switch u := body.(type) {
+	case nil:
+		r.body = nil
+	case string:
+		r.body = []byte(u)
+	case []byte:
+		r.body = u
 	default:
-		panic("mockDNS unknown url " + url)
+		s, err := Marshal(body)
+		if err != nil {
+			panic("fetch.Mock: failed to marshal reply body: " + err.Error())
+		}
+		r.body = []byte(s)
+		if r.header.Get("Content-Type") == "" {
+			r.header.Set("Content-Type", "application/json")
+		}
+	}
+	return r
+}
+
+// ReplyHeader adds the header to the response.
+func (r *MockRoute) ReplyHeader(key, value string) *MockRoute {
+	r.header.Add(key, value)
+	return r
+}
+
+// ReplyFunc makes the response dynamically. It overrides Reply.
+func (r *MockRoute) ReplyFunc(f func(req *http.Request) (*http.Response, error)) *MockRoute {
+	r.respond = f
+	return r
+}
+
+// Times sets how many times the route is expected to be called. Checked by Mock.Assert.
+func (r *MockRoute) Times(n int) *MockRoute {
+	r.times = n
+	return r
+}
+
+// Calls returns how many requests the route has served.
+func (r *MockRoute) Calls() int {
+	r.mock.mu.Lock()
+	defer r.mock.mu.Unlock()
+	return r.calls
+}
+
+// HttpClient returns http.Client sending its requests to the mock.
+func (m *Mock) HttpClient() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+func (m *Mock) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	m.mu.Lock()
+	route := m.find(req, body)
+	if route == nil {
+		m.unmatched = append(m.unmatched, req.Method+" "+req.URL.String())
+		m.mu.Unlock()
+		if m.FailUnmatched {
+			return nil, fmt.Errorf("fetch.Mock: no route for %s %s", req.Method, req.URL)
+		}
+		return mockResponse(req, 404, nil, []byte("no mock for "+req.Method+" "+req.URL.String())), nil
+	}
+	route.calls++
+	m.mu.Unlock()
+
+	if route.respond != nil {
+		return route.respond(req)
+	}
+	return mockResponse(req, route.status, route.header.Clone(), route.body), nil
+}
+
+func (m *Mock) find(req *http.Request, body []byte) *MockRoute {
+	for _, r := range m.routes {
+		if r.method != "" && r.method != "*" && r.method != req.Method {
+			continue
+		}
+		if !r.match(req) {
+			continue
+		}
+		matched := true
+		for _, cond := range r.conds {
+			if !cond(req, body) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return r
+		}
+	}
+	return nil
+}
+
+// TB is the subset of testing.TB used by Mock.Assert.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Assert reports the unmatched requests and the routes
+// which weren't called the number of times set by MockRoute.Times.
+func (m *Mock) Assert(t TB) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.unmatched {
+		t.Errorf("fetch.Mock: unmatched request %s", u)
+	}
+	for _, r := range m.routes {
+		if r.times >= 0 && r.calls != r.times {
+			t.Errorf("fetch.Mock: route %s %s expected %d calls, got %d", r.method, r.pattern, r.times, r.calls)
+		}
 	}
 }
 
-func (mr *mockResponse) response() *http.Response {
+// Reset removes all the routes and unmatched requests.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = nil
+	m.unmatched = nil
+}
+
+func mockResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
 	return &http.Response{
-		StatusCode:    mr.Status,
-		Header:        mr.Headers,
-		Body:          newBC(mr.Body),
-		ContentLength: int64(len([]byte(mr.Body))),
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// mockURL returns the part of the request URL the pattern should be matched against.
+func mockURL(pattern string, req *http.Request) string {
+	u := req.URL.Path
+	if !strings.HasPrefix(pattern, "/") {
+		u = req.URL.Host + u
+		if hasProtocol(pattern) {
+			u = req.URL.Scheme + "://" + u
+		}
+	}
+	if strings.Contains(pattern, "?") && req.URL.RawQuery != "" {
+		u += "?" + req.URL.RawQuery
+	}
+	return u
+}
+
+func matchPattern(pattern, u string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(u, pattern[:len(pattern)-1])
+	}
+	if !strings.Contains(pattern, "{") {
+		return pattern == u
+	}
+	ps, us := strings.Split(pattern, "/"), strings.Split(u, "/")
+	if len(ps) != len(us) {
+		return false
+	}
+	for i, p := range ps {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			if us[i] == "" {
+				return false
+			}
+			continue
+		}
+		if p != us[i] {
+			return false
+		}
 	}
+	return true
 }
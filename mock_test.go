@@ -0,0 +1,105 @@
+package fetch
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+type mockT struct {
+	errors []string
+}
+
+func (mt *mockT) Helper() {}
+
+func (mt *mockT) Errorf(format string, args ...any) {
+	mt.errors = append(mt.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMock_Patterns(t *testing.T) {
+	m := &Mock{FailUnmatched: true}
+	m.On("GET", "https://api.io/pets/{id}").Reply(200, "wildcard")
+	m.On("GET", "api.io/tags*").Reply(200, "prefix")
+	m.On("POST", "/pets").Reply(201, "path")
+	m.On("GET", "/search?q=cats").Reply(200, "query")
+	m.OnRegexp("", regexp.MustCompile(`/owners/\d+$`)).Reply(200, "regexp")
+	c := &Client{HttpClient: m.HttpClient()}
+
+	cases := map[string]string{
+		"https://api.io/pets/1":         "wildcard",
+		"api.io/tags/cats?limit=1":      "prefix",
+		"http://other.io/search?q=cats": "query",
+		"api.io/owners/12":              "regexp",
+	}
+	for u, want := range cases {
+		got, err := GetWith[string](c, u)
+		if err != nil || got != want {
+			t.Errorf("%s: got=%s, err=%v, want=%s", u, got, err, want)
+		}
+	}
+	if got, err := PostWith[string](c, "any.host/pets", nil); err != nil || got != "path" {
+		t.Errorf("path pattern: got=%s, err=%v", got, err)
+	}
+
+	for _, u := range []string{"https://api.io/pets/1/tags", "http://api.io/pets/1", "api.io/owners/x"} {
+		if _, err := GetWith[string](c, u); err == nil {
+			t.Errorf("%s shouldn't match", u)
+		}
+	}
+	mt := &mockT{}
+	m.Assert(mt)
+	if len(mt.errors) != 3 {
+		t.Errorf("expected 3 unmatched requests, got %v", mt.errors)
+	}
+}
+
+func TestMock_Conditions(t *testing.T) {
+	m := &Mock{}
+	auth := m.On("POST", "/pets").MatchHeader("Authorization", "secret").MatchBody(`{"name": "Lola", "age": 1}`).
+		ReplyHeader("Location", "/pets/1").Reply(201, Pet{Name: "Lola"}).Times(1)
+	m.On("POST", "/pets").Reply(401, "unauthorized")
+	c := &Client{HttpClient: m.HttpClient()}
+
+	res, err := PostWith[Response[Pet]](c, "pets.io/pets", M{"age": 1, "name": "Lola"}, Config{Headers: map[string]string{"Authorization": "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != 201 || res.Body.Name != "Lola" || res.Headers["Location"] != "/pets/1" {
+		t.Errorf("wrong response: %+v", res)
+	}
+
+	_, err = PostWith[Pet](c, "pets.io/pets", M{"name": "Lola"})
+	if err == nil || err.(*Error).Status != 401 {
+		t.Errorf("expected fallback route, got %v", err)
+	}
+
+	_, err = GetWith[Pet](c, "pets.io/pets")
+	if err == nil || err.(*Error).Status != 404 {
+		t.Errorf("expected 404 for unmatched request, got %v", err)
+	}
+
+	if auth.Calls() != 1 {
+		t.Errorf("expected one call, got %d", auth.Calls())
+	}
+	mt := &mockT{}
+	m.Assert(mt)
+	if len(mt.errors) != 1 {
+		t.Errorf("expected one unmatched request, got %v", mt.errors)
+	}
+}
+
+func TestMock_ReplyFunc(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "/pets/{id}").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(req, 200, nil, []byte(req.URL.Path)), nil
+	}).Times(2)
+	c := &Client{HttpClient: m.HttpClient()}
+	for _, id := range []string{"1", "2"} {
+		res, err := GetWith[string](c, "pets.io/pets/"+id)
+		if err != nil || res != "/pets/"+id {
+			t.Errorf("wrong response %s, err=%v", res, err)
+		}
+	}
+	m.Assert(t)
+}
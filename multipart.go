@@ -0,0 +1,158 @@
+package fetch
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+/*
+Multipart is a request body sent as multipart/form-data.
+The files are streamed without loading them into memory.
+e.g.
+
+	f, err := os.Open("lola.png")
+	...
+	defer f.Close()
+	_, err = fetch.Post[fetch.Empty]("/pets/1/photos", fetch.Multipart{
+		Fields: map[string]string{"title": "Lola"},
+		JSON:   map[string]any{"metadata": Metadata{Camera: "Pentax"}},
+		Files:  []fetch.File{{Field: "photo", Name: "lola.png", ContentType: "image/png", Reader: f}},
+	})
+
+A request with multipart body isn't retried.
+*/
+type Multipart struct {
+	// Text fields.
+	Fields map[string]string
+	// Fields marshaled into JSON and sent with application/json content type.
+	JSON  map[string]any
+	Files []File
+}
+
+// File is a file part of Multipart.
+type File struct {
+	// Name of the form field.
+	Field string
+	// File name.
+	Name string
+	// Defaults to application/octet-stream.
+	ContentType string
+	Reader      io.Reader
+}
+
+// requestBody is a request body which is streamed instead of Config.Body.
+type requestBody struct {
+	open        func() (io.Reader, error)
+	contentType string
+	// length of the body if it's known, zero otherwise.
+	length int64
+	// open can be called again to retry the request.
+	replayable bool
+}
+
+// newRequestBody converts the body types which aren't sent as JSON.
+func newRequestBody(body any) (*requestBody, bool, error) {
+	switch u := body.(type) {
+	case Multipart:
+		return u.body(), true, nil
+	case *Multipart:
+		return u.body(), true, nil
+	case Form:
+		rb, err := u.body()
+		return rb, true, err
+	case *Form:
+		rb, err := u.body()
+		return rb, true, err
+	case url.Values:
+		rb, err := Form{Value: u}.body()
+		return rb, true, err
+	case io.Reader:
+		return readerBody(u), true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func (m Multipart) body() *requestBody {
+	boundary := multipart.NewWriter(nil).Boundary()
+	return &requestBody{
+		contentType: "multipart/form-data; boundary=" + boundary,
+		open: func() (io.Reader, error) {
+			pr, pw := io.Pipe()
+			mw := multipart.NewWriter(pw)
+			err := mw.SetBoundary(boundary)
+			if err != nil {
+				return nil, err
+			}
+			go func() {
+				// the error is returned to the reader of the body.
+				pw.CloseWithError(m.write(mw))
+			}()
+			return pr, nil
+		},
+	}
+}
+
+func (m Multipart) write(mw *multipart.Writer) error {
+	for _, k := range sortedKeys(m.Fields) {
+		if err := mw.WriteField(k, m.Fields[k]); err != nil {
+			return err
+		}
+	}
+	for _, k := range sortedKeys(m.JSON) {
+		s, err := Marshal(m.JSON[k])
+		if err != nil {
+			return fmt.Errorf("marshal multipart field %s: %s", k, err)
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(k)))
+		h.Set("Content-Type", "application/json")
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, s); err != nil {
+			return err
+		}
+	}
+	for _, f := range m.Files {
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(f.Field), escapeQuotes(f.Name)))
+		h.Set("Content-Type", contentType)
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if f.Reader == nil {
+			continue
+		}
+		if _, err = io.Copy(w, f.Reader); err != nil {
+			return fmt.Errorf("read multipart file %s: %s", f.Name, err)
+		}
+	}
+	return mw.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
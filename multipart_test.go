@@ -0,0 +1,87 @@
+package fetch
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMultipart(t *testing.T) {
+	m := &Mock{}
+	m.On("POST", "/upload").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/form-data" {
+			return mockResponse(req, 400, nil, []byte("wrong content type")), nil
+		}
+		parts := M{}
+		r := multipart.NewReader(req.Body, params["boundary"])
+		for {
+			p, err := r.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			b, _ := io.ReadAll(p)
+			parts[p.FormName()] = p.FileName() + "|" + p.Header.Get("Content-Type") + "|" + string(b)
+		}
+		return mockResponse(req, 200, nil, []byte(parts.String())), nil
+	})
+	c := &Client{HttpClient: m.HttpClient()}
+
+	res, err := PostWith[map[string]string](c, "files.io/upload", &Multipart{
+		Fields: map[string]string{"title": "Lola"},
+		JSON:   map[string]any{"meta": Pet{Name: "Lola"}},
+		Files: []File{
+			{Field: "photo", Name: "lola.png", ContentType: "image/png", Reader: strings.NewReader("png bytes")},
+			{Field: "notes", Name: "notes.txt", Reader: strings.NewReader("good dog")},
+		},
+	}, Config{Retry: Retry{Attempts: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"title": "||Lola",
+		"meta":  `|application/json|{"name":"Lola"}`,
+		"photo": "lola.png|image/png|png bytes",
+		"notes": "notes.txt|application/octet-stream|good dog",
+	}
+	for k, v := range want {
+		if res[k] != v {
+			t.Errorf("part %s: got=%q, want=%q", k, res[k], v)
+		}
+	}
+}
+
+func TestMultipart_ReadError(t *testing.T) {
+	_, err := Post[string]("echo.me", Multipart{Files: []File{{Field: "f", Name: "f", Reader: &failingReader{}}}})
+	if err == nil || !strings.Contains(err.Error(), "read multipart file f") {
+		t.Errorf("expected read error, got %v", err)
+	}
+}
+
+type failingReader struct{}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestMultipart_NotSent(t *testing.T) {
+	var body io.Reader
+	respond := func(req *http.Request, next Next) (*http.Response, error) {
+		body = req.Body
+		return mockResponse(req, 200, nil, []byte("ok")), nil
+	}
+	res, err := PostWith[string](&Client{}, "pets.io/upload", Multipart{Fields: map[string]string{"name": "Lola"}}, Config{
+		Interceptors: []Interceptor{respond},
+	})
+	assert(t, err, nil)
+	assert(t, res, "ok")
+	// the closed body stops the writer of the multipart body.
+	_, err = body.Read(make([]byte, 1))
+	assert(t, err, io.ErrClosedPipe)
+}
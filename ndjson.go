@@ -0,0 +1,106 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+)
+
+/*
+GetStreamFunc makes GET request and decodes every line of the response body
+as a separate JSON value (NDJSON, JSON Lines) as soon as it arrives.
+yield is called for every value until it returns false or the body ends.
+Errors are passed to yield and end the stream, except for the canceled request context,
+which ends the stream without an error.
+e.g.
+
+	fetch.GetStreamFunc("https://example.com/events", func(e Event, err error) bool {
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		fmt.Println(e)
+		return true
+	})
+
+With go1.23 and above you can range over GetStream.
+*/
+func GetStreamFunc[T any](url string, yield func(T, error) bool, config ...Config) {
+	GetStreamFuncWith(defaultClient, url, yield, config...)
+}
+
+func GetStreamFuncWith[T any](c *Client, url string, yield func(T, error) bool, config ...Config) {
+	var t T
+	if c == nil {
+		c = defaultClient
+	}
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	cfg.Method = http.MethodGet
+	if !hasHeader(cfg, "Accept") {
+		cfg.Headers = mapWith(cfg.Headers, "Accept", "application/x-ndjson")
+	}
+	ex, ferr := c.exchange(url, cfg)
+	if ferr != nil {
+		yield(t, ferr)
+		return
+	}
+	defer ex.close()
+	if firstDigit(ex.res.StatusCode) != 2 {
+		body, _ := ex.readBody(ex.res.Body)
+		yield(t, ex.fail(statusErr(ex.res, body)))
+		return
+	}
+
+	r := bufio.NewReader(ex.res.Body)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && ex.req.Context().Err() != nil {
+			return
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var v T
+			perr := parseBodyInto(line, &v)
+			if perr != nil {
+				yield(t, ex.fail(httpErr("parse stream line: ", perr, ex.res, line)))
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			yield(t, ex.fail(httpErr("read http body: ", err, ex.res, nil)))
+			return
+		}
+	}
+}
+
+// hasHeader reports whether the header is set in Config.Headers or Config.Header.
+func hasHeader(cfg Config, name string) bool {
+	if len(cfg.Header.Values(name)) > 0 {
+		return true
+	}
+	for k := range cfg.Headers {
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapWith copies the map with the added key.
+func mapWith(m map[string]string, key, value string) map[string]string {
+	newM := make(map[string]string, len(m)+1)
+	for k, v := range m {
+		newM[k] = v
+	}
+	newM[key] = value
+	return newM
+}
@@ -0,0 +1,27 @@
+//go:build go1.23
+
+package fetch
+
+import "iter"
+
+/*
+GetStream makes GET request and returns the iterator over the JSON values
+of every line of the response body (NDJSON, JSON Lines). See GetStreamFunc.
+e.g.
+
+	for event, err := range fetch.GetStream[Event]("https://example.com/events") {
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(event)
+	}
+*/
+func GetStream[T any](url string, config ...Config) iter.Seq2[T, error] {
+	return GetStreamWith[T](defaultClient, url, config...)
+}
+
+func GetStreamWith[T any](c *Client, url string, config ...Config) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		GetStreamFuncWith(c, url, yield, config...)
+	}
+}
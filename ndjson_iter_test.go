@@ -0,0 +1,20 @@
+//go:build go1.23
+
+package fetch
+
+import "testing"
+
+func TestGetStream(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "/nums").Reply(200, "1\n2\n3\n")
+	var sum int
+	for n, err := range GetStreamWith[int](&Client{HttpClient: m.HttpClient()}, "nums.io/nums") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += n
+	}
+	if sum != 6 {
+		t.Errorf("wrong sum %d", sum)
+	}
+}
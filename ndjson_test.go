@@ -0,0 +1,98 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetStreamFunc(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "/pets").MatchHeader("Accept", "application/x-ndjson").Reply(200, "{\"name\":\"Lola\"}\n\n{\"name\":\"Charles\"}\n{\"name\":\"Max\"}")
+	m.On("GET", "/broken").Reply(200, "{\"name\":\"Lola\"}\n{\"name\":")
+	m.On("GET", "/missing").Reply(404, "not found")
+	c := &Client{HttpClient: m.HttpClient()}
+
+	var names []string
+	GetStreamFuncWith(c, "pets.io/pets", func(p Pet, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, p.Name)
+		return len(names) < 2
+	})
+	if len(names) != 2 || names[0] != "Lola" || names[1] != "Charles" {
+		t.Errorf("wrong pets: %v", names)
+	}
+
+	var errs []error
+	GetStreamFuncWith(c, "pets.io/broken", func(p Pet, err error) bool {
+		errs = append(errs, err)
+		return true
+	})
+	if len(errs) != 2 || errs[0] != nil || errs[1] == nil {
+		t.Errorf("expected parse error on the second line, got %v", errs)
+	}
+	// the parse error has the attempts and the timings like the other errors.
+	errs = nil
+	GetStreamFuncWith(c, "pets.io/broken", func(p Pet, err error) bool {
+		errs = append(errs, err)
+		return true
+	}, Config{Trace: true})
+	if len(errs) != 2 || errs[1].(*Error).Attempts != 1 || errs[1].(*Error).Timings == nil {
+		t.Errorf("expected parse error with attempts and timings, got %v", errs)
+	}
+
+	GetStreamFuncWith(c, "pets.io/missing", func(j J, err error) bool {
+		if err == nil || err.(*Error).Status != 404 {
+			t.Errorf("expected 404 error, got %v", err)
+		}
+		return true
+	})
+}
+
+func TestGetStreamFunc_Cancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; r.Context().Err() == nil; i++ {
+			w.Write([]byte("{\"name\":\"Lola\"}\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	GetStreamFuncWith(&Client{}, server.URL, func(p Pet, err error) bool {
+		if err != nil {
+			t.Errorf("canceled stream shouldn't return errors, got %v", err)
+			return false
+		}
+		count++
+		if count == 3 {
+			cancel()
+		}
+		return true
+	}, Config{Ctx: ctx})
+	if count < 3 {
+		t.Errorf("expected at least 3 pets, got %d", count)
+	}
+}
+
+func TestGetStreamFunc_AcceptHeader(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "/pets").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(req, 200, nil, []byte(strings.Join(req.Header.Values("Accept"), ","))), nil
+	})
+	c := &Client{HttpClient: m.HttpClient()}
+	var accept string
+	GetStreamFuncWith(c, "pets.io/pets", func(s string, err error) bool {
+		assert(t, err, nil)
+		accept = s
+		return true
+	}, Config{Header: http.Header{"Accept": {"application/jsonl"}}})
+	assert(t, accept, "application/jsonl")
+}
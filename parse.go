@@ -16,6 +16,21 @@ func Parse(s string) J {
 	return j
 }
 
+// JSONLimits protect Unmarshal from malicious JSON. Zero means no limit.
+type JSONLimits struct {
+	// Maximum nesting depth of objects and arrays.
+	MaxDepth int
+	// Maximum length of a string in bytes as it's encoded, including object keys.
+	MaxStringLength int
+	// Maximum number of elements in an array.
+	MaxArrayLength int
+}
+
+// SetJSONLimits sets the limits of the JSON parsed by Unmarshal, fetch requests and handlers.
+func SetJSONLimits(l JSONLimits) {
+	json.SetLimits(json.Limits(l))
+}
+
 // UnmarshalJ unmarshalls J into the generic value.
 func UnmarshalJ[T any](j J) (T, error) {
 	if isJNil(j) {
@@ -39,7 +54,7 @@ func UnmarshalInto(j string, v any) error {
 
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Pointer || rv.IsNil() {
-		return &json.InvalidUnmarshalError{reflect.TypeOf(v)}
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(v)}
 	}
 
 	rve := rv.Elem()
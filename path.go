@@ -0,0 +1,88 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+type routeKey struct{}
+
+// Route returns the URL of the request before substituting the path parameters,
+// e.g. /pets/{id}. The query is cut off. It suits as the low-cardinality name of the request
+// in logs and metrics. Returns empty string if the request wasn't made by fetch.
+func Route(req *http.Request) string {
+	route, _ := req.Context().Value(routeKey{}).(string)
+	return route
+}
+
+func withRoute(ctx context.Context, rawURL string) context.Context {
+	route, _, _ := strings.Cut(rawURL, "?")
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// expandPath substitutes the placeholders like {id} before the query with the percent-escaped parameters.
+// All the placeholders must have parameters and all the parameters must be used.
+func expandPath(template string, params map[string]any) (string, error) {
+	path, query, hasQuery := strings.Cut(template, "?")
+	used := make(map[string]bool, len(params))
+	var b strings.Builder
+	for {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed path parameter in %s", template)
+		}
+		end += start
+		name := path[start+1 : end]
+		v, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("missing path parameter %q", name)
+		}
+		s, err := pathParam(name, v)
+		if err != nil {
+			return "", err
+		}
+		used[name] = true
+		b.WriteString(path[:start])
+		b.WriteString(url.PathEscape(s))
+		path = path[end+1:]
+	}
+	b.WriteString(path)
+	var unused []string
+	for _, name := range sortedKeys(params) {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		return "", fmt.Errorf("unused path parameters %s", strings.Join(unused, ", "))
+	}
+	if hasQuery {
+		b.WriteString("?" + query)
+	}
+	return b.String(), nil
+}
+
+func pathParam(name string, v any) (string, error) {
+	if v == nil {
+		return "", fmt.Errorf("path parameter %q is nil", name)
+	}
+	s, ok, err := formScalar(reflect.ValueOf(v))
+	if err != nil {
+		return "", fmt.Errorf("path parameter %q: %w", name, err)
+	}
+	if !ok {
+		return "", fmt.Errorf("path parameter %q has unsupported type %T", name, v)
+	}
+	if s == "" {
+		return "", fmt.Errorf("path parameter %q is empty", name)
+	}
+	return s, nil
+}
@@ -0,0 +1,57 @@
+package fetch
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestConfig_PathParams(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "*").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(req, 200, nil, []byte(req.URL.EscapedPath()+" "+Route(req))), nil
+	})
+	c := &Client{BaseURL: "pets.io", HttpClient: m.HttpClient()}
+
+	res, err := GetWith[string](c, "/pets/{id}/tags/{tag}?limit=1", Config{PathParams: map[string]any{"id": 15, "tag": "cute/small"}})
+	assert(t, err, nil)
+	assert(t, res, "/pets/15/tags/cute%2Fsmall /pets/{id}/tags/{tag}")
+
+	res, err = GetWith[string](c, "/pets/1")
+	assert(t, err, nil)
+	assert(t, res, "/pets/1 /pets/1")
+}
+
+func TestConfig_PathParamsInvalid(t *testing.T) {
+	m := &Mock{FailUnmatched: true}
+	c := &Client{BaseURL: "pets.io", HttpClient: m.HttpClient()}
+
+	cases := []struct {
+		url    string
+		params map[string]any
+		msg    string
+	}{
+		{url: "/pets/{id}", params: map[string]any{}, msg: `invalid path: missing path parameter "id"`},
+		{url: "/pets/{id}", params: map[string]any{"id": 1, "tag": "cute"}, msg: "invalid path: unused path parameters tag"},
+		{url: "/pets/{id}", params: map[string]any{"id": ""}, msg: `invalid path: path parameter "id" is empty`},
+		{url: "/pets/{id}", params: map[string]any{"id": []int{1}}, msg: `invalid path: path parameter "id" has unsupported type []int`},
+		{url: "/pets/{id", params: map[string]any{"id": 1}, msg: "invalid path: unclosed path parameter in /pets/{id"},
+	}
+	for _, c2 := range cases {
+		_, err := GetWith[string](c, c2.url, Config{PathParams: c2.params})
+		if err == nil {
+			t.Fatalf("%s expected error", c2.url)
+		}
+		assert(t, err.Error(), c2.msg)
+	}
+}
+
+func TestConfig_PathWithoutParams(t *testing.T) {
+	m := &Mock{FailUnmatched: true}
+	m.On("GET", "x.io/search/*").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(req, 200, nil, []byte(req.URL.Path)), nil
+	})
+	c := &Client{HttpClient: m.HttpClient()}
+	res, err := GetWith[string](c, "x.io/search/{literal}")
+	assert(t, err, nil)
+	assert(t, res, "/search/{literal}")
+}
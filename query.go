@@ -0,0 +1,26 @@
+package fetch
+
+import (
+	"net/url"
+)
+
+// withQuery adds the query parameters to the URL, replacing the ones with the same keys.
+func withQuery(rawURL string, query any) (string, error) {
+	values, err := formValues(query)
+	if err != nil {
+		return "", err
+	}
+	if len(values) == 0 {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, v := range values {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,39 @@
+package fetch
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestConfig_Query(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "/pets").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(req, 200, nil, []byte(req.URL.RawQuery)), nil
+	})
+	c := &Client{HttpClient: m.HttpClient()}
+
+	type Filter struct {
+		Status  string
+		Tags    []string
+		Limit   int
+		Offset  int `json:"offset"`
+		Since   time.Time
+		Deleted bool
+	}
+	q, err := GetWith[string](c, "pets.io/pets?page=2&limit=1", Config{Query: Filter{
+		Status: "sold",
+		Tags:   []string{"cute", "small"},
+		Limit:  10,
+		Since:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}})
+	assert(t, err, nil)
+	assert(t, q, "limit=10&offset=0&page=2&since=2024-05-01T00%3A00%3A00Z&status=sold&tags=cute&tags=small")
+
+	q, err = GetWith[string](c, "pets.io/pets", Config{Query: map[string]string{"name": "Lola & Max"}})
+	assert(t, err, nil)
+	assert(t, q, "name=Lola+%26+Max")
+
+	_, err = GetWith[string](c, "pets.io/pets", Config{Query: "name=Lola"})
+	assertNotNil(t, err)
+}
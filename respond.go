@@ -1,6 +1,7 @@
 package fetch
 
 import (
+	"encoding"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -37,8 +38,18 @@ type respondConfig struct {
 	Status int
 	// Additional HTTP response headers.
 	Headers map[string]string
+	// Additional multi-valued HTTP response headers, replacing the ones of Headers.
+	Header http.Header
+	// Cookies sent as Set-Cookie headers.
+	Cookies []*http.Cookie
 	// HTTP response status in case of an error. Defaults to 500.
 	ErrorStatus int
+	// Accept header of the request, selecting the codec of the body. Defaults to JSON.
+	Accept string
+	// Accept-Encoding header of the request.
+	AcceptEncoding string
+	// Bodies of this size and larger are gzipped if the request accepts it. Zero disables compression.
+	CompressMinSize int
 }
 
 // respond tries to marshal the body and send HTTP response.
@@ -65,16 +76,18 @@ func respond(w http.ResponseWriter, body any, config ...respondConfig) error {
 			headers[mapRange.Key().String()] = mapRange.Value().String()
 		}
 		cfg.Headers = headers
+		cfg.Header, _ = wrapper.FieldByName("Header").Interface().(http.Header)
+		cfg.Cookies, _ = wrapper.FieldByName("Cookies").Interface().([]*http.Cookie)
 	}
 	var err error
 	if !isValidHTTPStatus(cfg.Status) {
 		err := fmt.Errorf("respondConfig.Status is invalid")
-		_ = doRespond(w, 500, fmt.Sprintf(respondErrorFormat, err), isRespondErrorFormatJSON, cfg)
+		_ = doRespond(w, 500, fmt.Sprintf(respondErrorFormat, err), errorContentType(), cfg)
 		return err
 	}
 	if !isValidHTTPStatus(cfg.ErrorStatus) {
 		err := fmt.Errorf("respondConfig.ErrorStatus is invalid")
-		_ = doRespond(w, 500, fmt.Sprintf(respondErrorFormat, err), isRespondErrorFormatJSON, cfg)
+		_ = doRespond(w, 500, fmt.Sprintf(respondErrorFormat, err), errorContentType(), cfg)
 		return err
 	}
 	var bodyStr string
@@ -91,35 +104,74 @@ func respond(w http.ResponseWriter, body any, config ...respondConfig) error {
 			isString = false
 		}
 	}
+	contentType := "text/plain"
 	if !isString {
+		var b []byte
 		if isResponseWrapper(body) {
-			bodyStr, err = Marshal(reflect.ValueOf(body).FieldByName("Body").Interface())
+			contentType, b, err = marshalNegotiated(reflect.ValueOf(body).FieldByName("Body").Interface(), cfg.Accept)
 		} else {
-			bodyStr, err = Marshal(body)
+			contentType, b, err = marshalNegotiated(body, cfg.Accept)
 		}
 		if err != nil {
-			_ = doRespond(w, cfg.ErrorStatus, fmt.Sprintf(respondErrorFormat, err), isRespondErrorFormatJSON, cfg)
+			_ = doRespond(w, cfg.ErrorStatus, fmt.Sprintf(respondErrorFormat, err), errorContentType(), cfg)
 			return fmt.Errorf("failed to marshal response body: %s", err)
 		}
+		bodyStr = string(b)
 	}
 
-	return doRespond(w, cfg.Status, bodyStr, !isString, cfg)
+	return doRespond(w, cfg.Status, bodyStr, contentType, cfg)
 }
 
-func doRespond(w http.ResponseWriter, status int, bodyStr string, isJSON bool, cfg respondConfig) error {
-	w.WriteHeader(status)
+// marshalNegotiated marshals the body with the codec accepted by the request.
+// It falls back to JSON if the codec can't marshal the body, e.g. XML of a map
+// or plain text of a value which isn't encoding.TextMarshaler.
+func marshalNegotiated(body any, accept string) (string, []byte, error) {
+	contentType, codec := negotiate(accept)
+	_, isText := codec.(textCodec)
+	_, isTextMarshaler := body.(encoding.TextMarshaler)
+	if !isText || isTextMarshaler {
+		b, err := codec.Marshal(body)
+		if err == nil || contentType == "application/json" {
+			return contentType, b, err
+		}
+	}
+	b, err := jsonCodec{}.Marshal(body)
+	return "application/json", b, err
+}
+
+// doRespond sets the headers before WriteHeader, which sends them and ignores the later changes.
+func doRespond(w http.ResponseWriter, status int, bodyStr string, contentType string, cfg respondConfig) error {
 	for k, v := range cfg.Headers {
 		w.Header().Set(k, v)
 	}
-	if isJSON {
-		w.Header().Set("Content-Type", "application/json")
-	} else {
-		w.Header().Set("Content-Type", "text/plain")
+	setHeader(w.Header(), cfg.Header)
+	for _, c := range cfg.Cookies {
+		http.SetCookie(w, c)
+	}
+	w.Header().Set("Content-Type", contentType)
+	if cfg.CompressMinSize > 0 && len(bodyStr) >= cfg.CompressMinSize && w.Header().Get("Content-Encoding") == "" {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if acceptsGzip(cfg.AcceptEncoding) {
+			gzipped, err := gzipString(bodyStr)
+			if err == nil {
+				bodyStr = gzipped
+				w.Header().Set("Content-Encoding", "gzip")
+				w.Header().Del("Content-Length")
+			}
+		}
 	}
+	w.WriteHeader(status)
 	_, err := w.Write([]byte(bodyStr))
 	return err
 }
 
+func errorContentType() string {
+	if isRespondErrorFormatJSON {
+		return "application/json"
+	}
+	return "text/plain"
+}
+
 // respondError sends HTTP response in the error format of respond.
 // It should be used when your handler experiences an error
 // before marshalling and responding with fetch.respond.
@@ -135,15 +187,12 @@ func respondError(w http.ResponseWriter, status int, errToRespond error, config
 		}
 		return fmt.Errorf("error status is invalid")
 	}
-	w.WriteHeader(status)
 	for k, v := range cfg.Headers {
 		w.Header().Set(k, v)
 	}
-	if isRespondErrorFormatJSON {
-		w.Header().Set("Content-Type", "application/json")
-	} else {
-		w.Header().Set("Content-Type", "text/plain")
-	}
+	setHeader(w.Header(), cfg.Header)
+	w.Header().Set("Content-Type", errorContentType())
+	w.WriteHeader(status)
 	bodyStr := fmt.Sprintf(respondErrorFormat, errToRespond.Error())
 	_, err := w.Write([]byte(bodyStr))
 	return err
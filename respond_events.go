@@ -0,0 +1,169 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const defaultHeartbeat = 15 * time.Second
+
+// isEventStream checks if the handler output is a channel or an iterator func(yield func(T) bool).
+func isEventStream(v any) bool {
+	typeOf := reflect.TypeOf(v)
+	if typeOf == nil {
+		return false
+	}
+	switch typeOf.Kind() {
+	case reflect.Chan:
+		return typeOf.ChanDir()&reflect.RecvDir != 0
+	case reflect.Func:
+		if typeOf.NumIn() != 1 || typeOf.NumOut() != 0 {
+			return false
+		}
+		yield := typeOf.In(0)
+		return yield.Kind() == reflect.Func && yield.NumIn() == 1 && yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool
+	default:
+		return false
+	}
+}
+
+func isEventWrapper(v any) bool {
+	typeOf := reflect.TypeOf(v)
+	return typeOf != nil && typeOf.PkgPath() == "github.com/glossd/fetch" && strings.HasPrefix(typeOf.Name(), "Event[")
+}
+
+// respondEvents streams the values of the channel or the iterator as Server-Sent Events
+// until it ends or the request context is canceled.
+func respondEvents(w http.ResponseWriter, r *http.Request, stream any, heartbeat time.Duration) error {
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+	}
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	values := eventChannel(ctx, reflect.ValueOf(stream))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(200)
+	flush(w)
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ticker.C)},
+		{Dir: reflect.SelectRecv, Chan: values},
+	}
+	for {
+		chosen, v, ok := reflect.Select(cases)
+		var msg string
+		switch chosen {
+		case 0:
+			return nil
+		case 1:
+			msg = ": heartbeat\n\n"
+		case 2:
+			if !ok {
+				return nil
+			}
+			var err error
+			msg, err = formatEvent(v.Interface())
+			if err != nil {
+				return err
+			}
+		}
+		_, err := w.Write([]byte(msg))
+		if err != nil {
+			return err
+		}
+		flush(w)
+	}
+}
+
+// eventChannel converts the iterator into a channel. A nil channel is treated as empty.
+func eventChannel(ctx context.Context, stream reflect.Value) reflect.Value {
+	if stream.Kind() == reflect.Chan {
+		if stream.IsNil() {
+			ch := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, stream.Type().Elem()), 0)
+			ch.Close()
+			return ch
+		}
+		return stream
+	}
+	yieldType := stream.Type().In(0)
+	ch := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, yieldType.In(0)), 0)
+	if stream.IsNil() {
+		ch.Close()
+		return ch
+	}
+	done := reflect.ValueOf(ctx.Done())
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		chosen, _, _ := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: ch, Send: args[0]},
+			{Dir: reflect.SelectRecv, Chan: done},
+		})
+		return []reflect.Value{reflect.ValueOf(chosen == 0)}
+	})
+	go func() {
+		defer ch.Close()
+		stream.Call([]reflect.Value{yield})
+	}()
+	return ch
+}
+
+func formatEvent(v any) (string, error) {
+	var sb strings.Builder
+	data := v
+	if isEventWrapper(v) {
+		e := reflect.ValueOf(v)
+		// a line break would end the field and inject the following text as other fields.
+		if id := e.FieldByName("ID").String(); id != "" {
+			if strings.ContainsAny(id, "\r\n") {
+				return "", fmt.Errorf("event id %q has a line break", id)
+			}
+			fmt.Fprintf(&sb, "id: %s\n", id)
+		}
+		if typ := e.FieldByName("Type").String(); typ != "" {
+			if strings.ContainsAny(typ, "\r\n") {
+				return "", fmt.Errorf("event type %q has a line break", typ)
+			}
+			fmt.Fprintf(&sb, "event: %s\n", typ)
+		}
+		if retry := time.Duration(e.FieldByName("Retry").Int()); retry > 0 {
+			fmt.Fprintf(&sb, "retry: %d\n", retry.Milliseconds())
+		}
+		data = e.FieldByName("Data").Interface()
+	}
+	var str string
+	switch u := data.(type) {
+	case string:
+		str = u
+	case []byte:
+		str = string(u)
+	default:
+		var err error
+		str, err = Marshal(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal event data: %s", err)
+		}
+	}
+	// clients break lines on CRLF, CR and LF.
+	str = strings.ReplaceAll(strings.ReplaceAll(str, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(str, "\n") {
+		fmt.Fprintf(&sb, "data: %s\n", line)
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,111 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToHandlerFunc_EventsChannel(t *testing.T) {
+	server := httptest.NewServer(ToHandlerFunc(func(in Empty) (<-chan Event[Pet], error) {
+		ch := make(chan Event[Pet])
+		go func() {
+			defer close(ch)
+			ch <- Event[Pet]{ID: "1", Type: "created", Data: Pet{Name: "Lola"}}
+			ch <- Event[Pet]{ID: "2", Data: Pet{Name: "Charles"}}
+		}()
+		return ch, nil
+	}))
+	defer server.Close()
+
+	var events []Event[Pet]
+	err := SubscribeWith(&Client{}, server.URL, func(e Event[Pet]) bool {
+		events = append(events, e)
+		return len(events) < 2
+	}, Config{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != "created" || events[0].Data.Name != "Lola" || events[1].ID != "2" {
+		t.Errorf("wrong events: %+v", events)
+	}
+}
+
+func TestToHandlerFunc_EventsIterator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	f := ToHandlerFunc(func(in Empty) (func(yield func(string) bool), error) {
+		return func(yield func(string) bool) {
+			defer close(stopped)
+			for i := 1; i <= 3; i++ {
+				if !yield(fmt.Sprintf("tick%d", i)) {
+					return
+				}
+			}
+			cancel()
+			for yield("late") {
+			}
+		}, nil
+	})
+	rec := httptest.NewRecorder()
+	r, err := http.NewRequestWithContext(ctx, "GET", "/ticks", bytes.NewBuffer(nil))
+	assert(t, err, nil)
+	f(rec, r)
+	assert(t, rec.Code, 200)
+	assert(t, rec.Header().Get("Content-Type"), "text/event-stream")
+	// the values yielded along with the cancellation may be sent.
+	body := rec.Body.String()
+	for strings.HasSuffix(body, "data: late\n\n") {
+		body = strings.TrimSuffix(body, "data: late\n\n")
+	}
+	assert(t, body, "data: tick1\n\ndata: tick2\n\ndata: tick3\n\n")
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Errorf("iterator should stop after the request is canceled")
+	}
+}
+
+func TestRespondEvents_Heartbeat(t *testing.T) {
+	ch := make(chan string)
+	rec := httptest.NewRecorder()
+	go func() {
+		ch <- "first"
+		time.Sleep(30 * time.Millisecond)
+		ch <- "second"
+		close(ch)
+	}()
+	err := respondEvents(rec, nil, ch, 10*time.Millisecond)
+	assert(t, err, nil)
+	body := rec.Body.String()
+	heartbeats := strings.Count(body, ": heartbeat\n\n")
+	if heartbeats < 1 {
+		t.Errorf("expected heartbeats, got %q", body)
+	}
+	assert(t, body, "data: first\n\n"+strings.Repeat(": heartbeat\n\n", heartbeats)+"data: second\n\n")
+}
+
+func TestFormatEvent(t *testing.T) {
+	s, err := formatEvent(Event[string]{ID: "7", Retry: time.Second, Data: "multi\nline"})
+	assert(t, err, nil)
+	assert(t, s, "id: 7\nretry: 1000\ndata: multi\ndata: line\n\n")
+	s, err = formatEvent(M{"name": "Lola"})
+	assert(t, err, nil)
+	assert(t, s, "data: {\"name\":\"Lola\"}\n\n")
+	s, err = formatEvent("cr\rcrlf\r\nlf\nend")
+	assert(t, err, nil)
+	assert(t, s, "data: cr\ndata: crlf\ndata: lf\ndata: end\n\n")
+	for _, e := range []Event[string]{{ID: "1\ndata: injected"}, {ID: "1\r"}, {Type: "ping\n\ndata: injected"}, {Type: "ping\revent: other"}} {
+		if s, err = formatEvent(e); err == nil {
+			t.Errorf("line break in id or type should fail, got %q", s)
+		}
+	}
+	if isEventStream(func() {}) || isEventStream("") || !isEventStream(make(chan int)) {
+		t.Errorf("wrong event stream detection")
+	}
+}
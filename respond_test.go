@@ -31,7 +31,7 @@ func (mw *mockWriter) Header() http.Header {
 
 func (mw *mockWriter) Write(b []byte) (int, error) {
 	mw.body = string(b)
-	return 0, nil
+	return len(b), nil
 }
 
 func TestRespond_String(t *testing.T) {
@@ -0,0 +1,164 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var defaultRetryStatuses = []int{429, 502, 503, 504}
+
+/*
+Retry configures repeating of the failed requests.
+The request is repeated on connection errors and the responses with the retryable statuses.
+Other errors, e.g. of the interceptors, are returned right away.
+The Retry-After header of the response is honored, but retrying stops if it's longer than MaxBackoff
+or if the next attempt can't be made before the deadline of the request context.
+e.g.
+
+	fetch.Get[Pet]("/pets/1", fetch.Config{Retry: fetch.Retry{Attempts: 3}})
+*/
+type Retry struct {
+	// Maximum number of requests including the first one.
+	// Zero or one means no retries.
+	Attempts int
+	// Delay before the first retry, doubled for each next one.
+	// A random jitter of up to half of the delay is added. Defaults to 100ms.
+	Backoff time.Duration
+	// Upper limit of the delay, including Retry-After. Defaults to 10s.
+	MaxBackoff time.Duration
+	// Response statuses to retry. Defaults to 429, 502, 503 and 504.
+	Statuses []int
+	// Don't retry when the request fails without HTTP response e.g. connection refused.
+	IgnoreConnErrors bool
+}
+
+// delay returns the time to wait before the next attempt, false if Retry-After exceeds MaxBackoff.
+func (r Retry) delay(attempt int, res *http.Response) (time.Duration, bool) {
+	maxBackoff := r.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+	if res != nil {
+		if d, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+			return d, d <= maxBackoff
+		}
+	}
+	backoff := r.Backoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	d := backoff << (attempt - 1)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	return d + time.Duration(rand.Int63n(int64(d/2)+1)), true
+}
+
+func (r Retry) retryable(status int) bool {
+	if r.Statuses == nil {
+		return slices.Contains(defaultRetryStatuses, status)
+	}
+	return slices.Contains(r.Statuses, status)
+}
+
+// retryAfter parses the value of Retry-After header, which is either seconds or HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// send makes the request, repeating it according to the retry policy.
+// Apart from the response it returns the errors of the failed attempts before it.
+func (c *Client) send(req *http.Request, retry Retry, next Next) (*http.Response, []error, error) {
+	var failed []error
+	// a streamed body can't be sent again.
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, failed, err
+			}
+			req.Body = body
+		}
+		if ex := exchangeOf(req); ex != nil {
+			ex.attempt = attempt
+		}
+		res, err := next(req)
+		if res == nil && err == nil {
+			err = errors.New("no response from interceptor")
+		}
+		last := attempt >= retry.Attempts || !replayable
+		if err != nil {
+			if last || retry.IgnoreConnErrors || req.Context().Err() != nil || !isConnError(err) {
+				return nil, failed, err
+			}
+			failed = append(failed, err)
+		} else {
+			if last || !retry.retryable(res.StatusCode) {
+				return res, failed, nil
+			}
+			body, _ := exchangeOf(req).readBody(res.Body)
+			res.Body.Close()
+			failed = append(failed, statusErr(res, body))
+		}
+		if d, ok := retry.delay(attempt, res); !ok || !wait(req.Context(), d) {
+			return nil, failed[:len(failed)-1], failed[len(failed)-1]
+		}
+	}
+}
+
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	decodeResponse(res)
+	return res, nil
+}
+
+// isConnError reports whether the transport failed to get the response, e.g. connection refused or reset.
+func isConnError(err error) bool {
+	// url.Error of http.Client is net.Error itself, whatever it wraps.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// wait sleeps for the duration unless the context ends before it.
+func wait(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
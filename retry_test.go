@@ -0,0 +1,139 @@
+package fetch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	withoutMock(t)
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, r.ContentLength)
+		r.Body.Read(body)
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(503)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	res, err := Post[string](server.URL, "replayed", Config{Retry: Retry{Attempts: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "replayed" || calls.Load() != 3 {
+		t.Errorf("expected replayed body on third attempt, got=%s, calls=%d", res, calls.Load())
+	}
+
+	calls.Store(0)
+	_, err = Post[string](server.URL, "", Config{Retry: Retry{Attempts: 2}})
+	ferr := err.(*Error)
+	if ferr.Status != 503 || ferr.Attempts != 2 || len(ferr.AttemptErrors) != 2 || ferr.AttemptErrors[1] != ferr {
+		t.Errorf("wrong error after retries: %+v", ferr)
+	}
+
+	calls.Store(0)
+	_, err = Post[string](server.URL, "", Config{Retry: Retry{Attempts: 3, Statuses: []int{500}}})
+	if err.(*Error).Attempts != 1 {
+		t.Errorf("503 shouldn't be retried, got=%d attempts", err.(*Error).Attempts)
+	}
+}
+
+func TestRetry_ConnError(t *testing.T) {
+	withoutMock(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	_, err := Get[Empty](server.URL, Config{Retry: Retry{Attempts: 3, Backoff: time.Millisecond}})
+	if err.(*Error).Attempts != 3 {
+		t.Errorf("expected 3 attempts, got=%d", err.(*Error).Attempts)
+	}
+	_, err = Get[Empty](server.URL, Config{Retry: Retry{Attempts: 3, IgnoreConnErrors: true}})
+	if err.(*Error).Attempts != 1 {
+		t.Errorf("expected 1 attempt, got=%d", err.(*Error).Attempts)
+	}
+	_, err = Get[Empty](server.URL, Config{Retry: Retry{Attempts: 3, Backoff: time.Second}, Timeout: 100 * time.Millisecond})
+	if err.(*Error).Attempts != 1 {
+		t.Errorf("retry shouldn't exceed the deadline, got=%d attempts", err.(*Error).Attempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	d, ok := retryAfter("3")
+	if !ok || d != 3*time.Second {
+		t.Errorf("wrong seconds, got=%s", d)
+	}
+	d, ok = retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if !ok || d < 59*time.Minute {
+		t.Errorf("wrong date, got=%s", d)
+	}
+	if _, ok = retryAfter("soon"); ok {
+		t.Errorf("invalid value should be ignored")
+	}
+}
+
+func TestRetry_Delay(t *testing.T) {
+	r := Retry{Backoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+	if d, _ := r.delay(1, nil); d < 10*time.Millisecond || d > 15*time.Millisecond {
+		t.Errorf("wrong first delay: %s", d)
+	}
+	if d, _ := r.delay(5, nil); d < 30*time.Millisecond || d > 45*time.Millisecond {
+		t.Errorf("delay should be limited, got: %s", d)
+	}
+}
+
+func TestRetry_RetryAfterLimit(t *testing.T) {
+	var calls atomic.Int32
+	m := &Mock{}
+	m.On("GET", "pets.io/pets").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return mockResponse(req, 503, http.Header{"Retry-After": {"86400"}}, []byte("maintenance")), nil
+	})
+	c := &Client{HttpClient: m.HttpClient()}
+	start := time.Now()
+	_, err := GetWith[string](c, "pets.io/pets", Config{Retry: Retry{Attempts: 3}})
+	if time.Since(start) > time.Second {
+		t.Errorf("Retry-After above MaxBackoff shouldn't be waited")
+	}
+	assertNotNil(t, err)
+	assert(t, err.(*Error).Status, 503)
+	assert(t, err.(*Error).Attempts, 1)
+	assert(t, calls.Load(), int32(1))
+
+	r := Retry{MaxBackoff: time.Minute}
+	d, ok := r.delay(1, &http.Response{Header: http.Header{"Retry-After": {"30"}}})
+	assert(t, ok, true)
+	assert(t, d, 30*time.Second)
+	_, ok = r.delay(1, &http.Response{Header: http.Header{"Retry-After": {"61"}}})
+	assert(t, ok, false)
+}
+
+func TestRetry_InterceptorError(t *testing.T) {
+	m := &Mock{FailUnmatched: true}
+	var attempts atomic.Int32
+	count := func(req *http.Request, next Next) (*http.Response, error) {
+		attempts.Add(1)
+		return next(req)
+	}
+	c := &Client{HttpClient: m.HttpClient(), Interceptors: []Interceptor{count}}
+	_, err := GetWith[string](c, "pets.io/unknown", Config{Retry: Retry{Attempts: 3, Backoff: time.Millisecond}})
+	assertNotNil(t, err)
+	assert(t, err.(*Error).Attempts, 1)
+	assert(t, attempts.Load(), int32(1))
+
+	failing := func(req *http.Request, next Next) (*http.Response, error) {
+		attempts.Add(1)
+		return nil, errors.New("no token")
+	}
+	attempts.Store(0)
+	_, err = GetWith[string](&Client{}, "pets.io/pets", Config{Retry: Retry{Attempts: 3, Backoff: time.Millisecond}, Interceptors: []Interceptor{failing}})
+	assertNotNil(t, err)
+	assert(t, attempts.Load(), int32(1))
+}
@@ -0,0 +1,158 @@
+package fetch
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSSERetry = 3 * time.Second
+
+// Event is a Server-Sent Event with the data of T type.
+type Event[T any] struct {
+	// Last event ID, sent in Last-Event-ID header on reconnection.
+	ID string
+	// Event type. Empty means "message".
+	Type string
+	Data T
+	// Reconnection delay requested by the server.
+	Retry time.Duration
+}
+
+/*
+Subscribe consumes Server-Sent Events from the URL. The data of every event is unmarshalled into T,
+multiline data is joined with newline. onEvent is called for every event until it returns false.
+After a disconnect Subscribe reconnects with Last-Event-ID header honoring the retry delay of the server.
+Subscribe returns nil once onEvent returns false or the request context ends.
+Non-2xx responses are returned as *fetch.Error.
+e.g.
+
+	err := fetch.Subscribe("https://example.com/notifications", func(e fetch.Event[Notification]) bool {
+		fmt.Println(e.Type, e.Data)
+		return true
+	}, fetch.Config{Ctx: ctx})
+*/
+func Subscribe[T any](url string, onEvent func(Event[T]) bool, config ...Config) error {
+	return SubscribeWith(defaultClient, url, onEvent, config...)
+}
+
+func SubscribeWith[T any](c *Client, url string, onEvent func(Event[T]) bool, config ...Config) error {
+	if c == nil {
+		c = defaultClient
+	}
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodGet
+	}
+	cfg.Headers = mapWith(cfg.Headers, "Accept", "text/event-stream")
+	cfg.Headers["Cache-Control"] = "no-cache"
+	if cfg.Ctx == nil {
+		// Config.Timeout limits the whole subscription, Client.Timeout is ignored.
+		cfg.Ctx = context.Background()
+		if cfg.Timeout > 0 {
+			var cancel context.CancelFunc
+			cfg.Ctx, cancel = context.WithTimeout(cfg.Ctx, cfg.Timeout)
+			defer cancel()
+		}
+	}
+
+	s := sseState{retry: defaultSSERetry}
+	connected := false
+	for {
+		if s.lastID != "" {
+			cfg.Headers["Last-Event-ID"] = s.lastID
+		}
+		ex, ferr := c.exchange(url, cfg)
+		if ferr != nil {
+			if !connected {
+				return ferr
+			}
+		} else {
+			if ex.res.StatusCode == http.StatusNoContent {
+				ex.close()
+				return nil
+			}
+			if firstDigit(ex.res.StatusCode) != 2 {
+				body, _ := ex.readBody(ex.res.Body)
+				ex.close()
+				return ex.fail(statusErr(ex.res, body))
+			}
+			connected = true
+			stop, err := readEvents(ex, &s, onEvent)
+			ex.close()
+			if err != nil || stop {
+				return err
+			}
+		}
+		if !wait(cfg.Ctx, s.retry) {
+			return nil
+		}
+	}
+}
+
+type sseState struct {
+	lastID string
+	retry  time.Duration
+}
+
+// readEvents reads the events until the body ends. It returns true if onEvent stopped the stream.
+func readEvents[T any](ex *exchange, s *sseState, onEvent func(Event[T]) bool) (bool, error) {
+	r := bufio.NewReader(ex.res.Body)
+	var typ string
+	var data []string
+	hasData := false
+	// the id becomes the last event ID once its event is dispatched, an incomplete event doesn't change it.
+	id := s.lastID
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			// an incomplete event is discarded.
+			if ex.req.Context().Err() != nil {
+				return true, nil
+			}
+			return false, nil
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line == "" {
+			s.lastID = id
+			if hasData {
+				e := Event[T]{ID: id, Type: typ, Retry: s.retry}
+				perr := parseBodyInto([]byte(strings.Join(data, "\n")), &e.Data)
+				if perr != nil {
+					return true, httpErr("parse event data: ", perr, ex.res, []byte(strings.Join(data, "\n")))
+				}
+				if !onEvent(e) {
+					return true, nil
+				}
+			}
+			typ, data, hasData = "", nil, false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			typ = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				id = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package fetch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			w.WriteHeader(400)
+			return
+		}
+		if connections.Add(1) == 1 {
+			fmt.Fprint(w, ": comment\nretry: 10\n\nid: 1\nevent: created\ndata: {\"name\":\n")
+			fmt.Fprint(w, "data: \"Lola\"}\n\nid: 2\ndata: {\"name\":\"Charles\"}\r\n\r\ndata: {\"incomplete")
+			return
+		}
+		fmt.Fprintf(w, "data: {\"name\":\"%s\"}\n\n", r.Header.Get("Last-Event-ID"))
+	}))
+	defer server.Close()
+
+	var events []Event[Pet]
+	err := SubscribeWith(&Client{}, server.URL, func(e Event[Pet]) bool {
+		events = append(events, e)
+		return len(events) < 3
+	}, Config{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	first := events[0]
+	if first.ID != "1" || first.Type != "created" || first.Data.Name != "Lola" || first.Retry != 10*time.Millisecond {
+		t.Errorf("wrong first event: %+v", first)
+	}
+	if events[1].Data.Name != "Charles" || events[1].Type != "" {
+		t.Errorf("wrong second event: %+v", events[1])
+	}
+	if events[2].Data.Name != "2" {
+		t.Errorf("expected reconnection with Last-Event-ID, got %+v", events[2])
+	}
+}
+
+func TestSubscribe_DisconnectMidEvent(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if connections.Add(1) == 1 {
+			fmt.Fprint(w, "retry: 1\n\nid: 1\ndata: first\n\nid: 2\ndata: sec")
+			return
+		}
+		if r.Header.Get("Last-Event-ID") != "1" {
+			w.WriteHeader(400)
+			return
+		}
+		fmt.Fprint(w, "id: 2\ndata: second\n\n")
+	}))
+	defer server.Close()
+
+	var events []Event[string]
+	err := SubscribeWith(&Client{}, server.URL, func(e Event[string]) bool {
+		events = append(events, e)
+		return len(events) < 2
+	}, Config{Timeout: time.Second})
+	assert(t, err, nil)
+	assert(t, len(events), 2)
+	assert(t, events[0].ID+" "+events[0].Data, "1 first")
+	// the cut off event isn't skipped after reconnecting.
+	assert(t, events[1].ID+" "+events[1].Data, "2 second")
+}
+
+func TestSubscribe_Errors(t *testing.T) {
+	m := &Mock{}
+	m.On("GET", "/forbidden").Reply(403, "forbidden")
+	m.On("GET", "/done").Reply(204, nil)
+	m.On("GET", "/strings").Reply(200, "data: hello\ndata: world\n\n")
+	c := &Client{HttpClient: m.HttpClient()}
+
+	err := SubscribeWith(c, "sse.io/forbidden", func(e Event[J]) bool { return true })
+	if err == nil || err.(*Error).Status != 403 {
+		t.Errorf("expected 403 error, got %v", err)
+	}
+	err = SubscribeWith(c, "sse.io/done", func(e Event[J]) bool { return true })
+	if err != nil {
+		t.Errorf("204 should end the subscription, got %v", err)
+	}
+	err = SubscribeWith(c, "sse.io/strings", func(e Event[string]) bool {
+		if e.Data != "hello\nworld" {
+			t.Errorf("wrong data %q", e.Data)
+		}
+		return false
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,57 @@
+package fetch
+
+import (
+	"io"
+)
+
+/*
+Stream is the response with the unread body. It lets you read large bodies
+without loading them into memory. The caller must close the body.
+e.g.
+
+	res, err := fetch.Get[fetch.Stream]("https://example.com/export.csv")
+	if err != nil {
+		panic(err)
+	}
+	defer res.Body.Close()
+	io.Copy(file, res.Body)
+
+Use io.ReadCloser as the response type if you need only the body.
+Non-2xx responses are still read and returned as *fetch.Error.
+*/
+type Stream = Response[io.ReadCloser]
+
+func isStreamType[T any]() bool {
+	typeOf := reflectTypeFor[T]()
+	return typeOf == reflectTypeFor[io.ReadCloser]() || typeOf == reflectTypeFor[Stream]()
+}
+
+func streamResponse[T any](ex *exchange) T {
+	var t T
+	body := &streamBody{ReadCloser: ex.res.Body, ex: ex}
+	switch u := any(&t).(type) {
+	case *io.ReadCloser:
+		*u = body
+	case *Stream:
+		u.Status = ex.res.StatusCode
+		u.Headers = mapFlatten(ex.res.Header)
+		u.Header = ex.res.Header
+		u.Cookies = ex.res.Cookies()
+		u.Cached = ex.cached
+		u.Timings = ex.trace.result()
+		u.Body = body
+	}
+	return t
+}
+
+// streamBody releases the request context once the body is closed.
+type streamBody struct {
+	io.ReadCloser
+	ex *exchange
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.ex.cancel()
+	return err
+}
@@ -0,0 +1,56 @@
+package fetch
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	body, err := Get[io.ReadCloser]("my.ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(body)
+	body.Close()
+	if err != nil || string(b) != "8.8.8.8" {
+		t.Errorf("wrong body: %s, err=%v", b, err)
+	}
+
+	res, err := Get[Stream]("key.value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, _ = io.ReadAll(res.Body)
+	if res.Status != 200 || res.Headers["Content-Type"] != "application/json" || string(b) != `{"key":"value"}` {
+		t.Errorf("wrong stream: %+v, body=%s", res, b)
+	}
+
+	_, err = Get[Stream]("400.error")
+	if err == nil || err.(*Error).Body != "Bad Request" {
+		t.Errorf("expected error with body, got %v", err)
+	}
+}
+
+func TestStream_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first;"))
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("second"))
+	}))
+	defer server.Close()
+
+	body, err := GetWith[io.ReadCloser](&Client{}, server.URL, Config{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	b, err := io.ReadAll(body)
+	if err != nil || string(b) != "first;second" {
+		t.Errorf("the body should be readable after return, got=%s, err=%v", b, err)
+	}
+}
@@ -0,0 +1,106 @@
+package fetch
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings is the breakdown of the request duration, recorded if Config.Trace is set.
+// The phases are of the last attempt, DNS, Connect and TLSHandshake are zero if the connection was reused.
+type Timings struct {
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	// Server is the time from writing the request to the first byte of the response.
+	Server time.Duration
+	// TTFB is the time from the start of the attempt to the first byte of the response.
+	TTFB time.Duration
+	// Total is the time of all the attempts including reading the body.
+	Total time.Duration
+	// ConnReused is true if the request was sent over a connection of the previous requests.
+	ConnReused bool
+}
+
+// tracer records the timings of the request via httptrace.
+type tracer struct {
+	mu      sync.Mutex
+	start   time.Time
+	attempt time.Time
+	dns     time.Time
+	connect time.Time
+	tls     time.Time
+	wrote   time.Time
+	timings Timings
+}
+
+func newTracer() *tracer {
+	return &tracer{start: time.Now()}
+}
+
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.record(func(now time.Time) {
+				t.attempt = now
+				t.timings = Timings{}
+			})
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func(time.Time) { t.timings.ConnReused = info.Reused })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func(now time.Time) { t.dns = now })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func(now time.Time) { t.timings.DNS = now.Sub(t.dns) })
+		},
+		ConnectStart: func(string, string) {
+			t.record(func(now time.Time) { t.connect = now })
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.record(func(now time.Time) { t.timings.Connect = now.Sub(t.connect) })
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.record(func(now time.Time) { t.tls = now })
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				t.record(func(now time.Time) { t.timings.TLSHandshake = now.Sub(t.tls) })
+			}
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.record(func(now time.Time) { t.wrote = now })
+		},
+		GotFirstResponseByte: func() {
+			t.record(func(now time.Time) {
+				t.timings.TTFB = now.Sub(t.attempt)
+				if !t.wrote.IsZero() {
+					t.timings.Server = now.Sub(t.wrote)
+				}
+			})
+		},
+	}
+}
+
+func (t *tracer) record(f func(now time.Time)) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f(now)
+}
+
+// result returns the timings with the total time until now. Nil tracer returns nil.
+func (t *tracer) result() *Timings {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timings := t.timings
+	timings.Total = time.Since(t.start)
+	return &timings
+}
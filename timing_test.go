@@ -0,0 +1,44 @@
+package fetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConfig_Trace(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		if r.URL.Path == "/error" {
+			w.WriteHeader(500)
+		}
+		w.Write([]byte(`{"name":"Lola"}`))
+	}))
+	defer ts.Close()
+	c := &Client{HttpClient: &http.Client{Transport: &http.Transport{}}}
+
+	res, err := GetWith[Response[Pet]](c, ts.URL, Config{Trace: true})
+	assert(t, err, nil)
+	tm := res.Timings
+	assertNotNil(t, tm)
+	assert(t, tm.ConnReused, false)
+	if tm.Connect <= 0 || tm.Server < 10*time.Millisecond || tm.TTFB < tm.Server || tm.Total < tm.TTFB {
+		t.Errorf("wrong timings: %+v", tm)
+	}
+
+	res, err = GetWith[Response[Pet]](c, ts.URL, Config{Trace: true})
+	assert(t, err, nil)
+	assert(t, res.Timings.ConnReused, true)
+	assert(t, res.Timings.Connect, time.Duration(0))
+
+	_, err = GetWith[Pet](c, ts.URL+"/error", Config{Trace: true})
+	assertNotNil(t, err)
+	assertNotNil(t, err.(*Error).Timings)
+
+	res, err = GetWith[Response[Pet]](c, ts.URL)
+	assert(t, err, nil)
+	if res.Timings != nil {
+		t.Errorf("expected no timings")
+	}
+}
@@ -1,18 +1,18 @@
 package fetch
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"reflect"
 	"runtime"
 	"strings"
+	"time"
 )
 
 var defaultHandlerConfig = HandlerConfig{
-	ErrorHook: func(err error) {
-		fmt.Printf("fetch.Handle failed to respond: %s\n", err)
-	},
 	Middleware: func(w http.ResponseWriter, r *http.Request) bool {
 		return false
 	},
@@ -30,18 +30,57 @@ func SetHandlerConfig(hc HandlerConfig) {
 }
 
 type HandlerConfig struct {
-	// ErrorHook is called if an error happens while sending an HTTP response
+	// ErrorHook is called if an error happens while sending an HTTP response.
+	// Defaults to logging the error with Logger or slog.Default().
 	ErrorHook func(err error)
 	// Middleware is applied before ToHandlerFunc processes the request.
 	// Return true to end the request processing.
 	Middleware func(w http.ResponseWriter, r *http.Request) bool
+	// MaxRequestSize limits the size of the request body in bytes,
+	// larger requests get 413 Request Entity Too Large. Zero means no limit.
+	MaxRequestSize int64
+	// Responses of this size and larger are gzipped if the request accepts it.
+	// Defaults to 1024 bytes, negative disables the compression.
+	CompressMinSize int
+	// Logger records every handled request. See LogOptions for the levels.
+	Logger     *slog.Logger
+	LogOptions LogOptions
+	// Metrics observes every handled request.
+	Metrics Metrics
+	// Heartbeat is the interval of the comments sent to keep the Server-Sent Events connection alive.
+	// Defaults to 15 seconds.
+	Heartbeat time.Duration
+}
+
+func (cfg HandlerConfig) compressMinSize() int {
+	if cfg.CompressMinSize == 0 {
+		return defaultCompressMinSize
+	}
+	return max(cfg.CompressMinSize, 0)
+}
+
+func (cfg HandlerConfig) errorHook(err error) {
+	if cfg.ErrorHook != nil {
+		cfg.ErrorHook(err)
+		return
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Error("fetch.Handle failed to respond", "error", err)
 }
 
 func (cfg HandlerConfig) respondError(w http.ResponseWriter, err error) {
-	cfg.ErrorHook(err)
-	err = respondError(w, 400, err)
+	cfg.errorHook(err)
+	status := 400
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	err = respondError(w, status, err)
 	if err != nil {
-		cfg.ErrorHook(err)
+		cfg.errorHook(err)
 	}
 }
 
@@ -61,59 +100,107 @@ which can be used later in http.ServeMux#HandleFunc.
 It unmarshals the HTTP request body into the ApplyFunc argument and
 then marshals the returned value into the HTTP response body.
 To access HTTP request attributes, wrap your input in fetch.Request.
+If Out is a channel or an iterator func(yield func(T) bool), its values are streamed
+as Server-Sent Events until it ends or the request is canceled. Use fetch.Event as T to set the event attributes.
 */
 func ToHandlerFunc[In any, Out any](apply ApplyFunc[In, Out]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		cfg := defaultHandlerConfig
-		if cfg.Middleware(w, r) {
+		if (cfg.Logger == nil && cfg.Metrics == nil) || r == nil {
+			handle(cfg, apply, w, r)
 			return
 		}
-		var in In
-		if isRequestWrapper(in) {
-			typeOf := reflect.TypeOf(in)
-			resType, ok := typeOf.FieldByName("Body")
-			if !ok {
-				panic("field Body is not found in Request")
-			}
-			resInstance := reflect.New(resType.Type).Interface()
-			if !isEmptyType(resInstance) {
-				err := readAndParseBody(r, resInstance)
-				if err != nil {
-					cfg.respondError(w, err)
-					return
-				}
-			}
-			valueOf := reflect.Indirect(reflect.ValueOf(&in))
-			valueOf.FieldByName("PathValues").Set(reflect.ValueOf(extractPathValues(r)))
-			valueOf.FieldByName("Context").Set(reflect.ValueOf(r.Context()))
-			valueOf.FieldByName("Parameters").Set(reflect.ValueOf(mapFlatten(r.URL.Query())))
-			valueOf.FieldByName("Headers").Set(reflect.ValueOf(mapFlatten(r.Header)))
-			valueOf.FieldByName("Body").Set(reflect.ValueOf(resInstance).Elem())
-		} else if !isEmptyType(in) {
-			err := readAndParseBody(r, &in)
-			if err != nil {
-				cfg.respondError(w, err)
-				return
+		start := time.Now()
+		event := MetricsEvent{Server: true, Method: r.Method, Route: routePattern(r)}
+		if cfg.Metrics != nil {
+			cfg.Metrics.RequestStart(event)
+		}
+		capture := 0
+		if cfg.Logger != nil {
+			capture = cfg.LogOptions.BodySize
+		}
+		lw := &observedWriter{ResponseWriter: w, capture: capture}
+		reqBody := &observedBody{ReadCloser: r.Body, capture: capture, onClose: func(int64, []byte) {}}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = reqBody
+		}
+		err := handle(cfg, apply, lw, r)
+		if cfg.Logger != nil {
+			cfg.logHandled(lw, r, start, reqBody.captured, err)
+		}
+		if cfg.Metrics != nil {
+			event.Status = lw.status
+			if event.Status == 0 {
+				event.Status = http.StatusOK
 			}
+			event.StatusClass, event.Duration = statusClass(event.Status), time.Since(start)
+			event.RequestBytes, event.ResponseBytes, event.Err = reqBody.read, lw.written, err
+			cfg.Metrics.RequestEnd(event)
 		}
+	}
+}
 
-		out, err := apply(in)
-		if err != nil {
-			status := 500
-			if erro, ok := err.(*Error); ok {
-				status = erro.Status
-			}
-			err = respondError(w, status, err)
+// handle processes the request, returning the error which failed it.
+func handle[In any, Out any](cfg HandlerConfig, apply ApplyFunc[In, Out], w http.ResponseWriter, r *http.Request) error {
+	if cfg.Middleware(w, r) {
+		return nil
+	}
+	var in In
+	if isRequestWrapper(in) {
+		typeOf := reflect.TypeOf(in)
+		resType, ok := typeOf.FieldByName("Body")
+		if !ok {
+			panic("field Body is not found in Request")
+		}
+		resInstance := reflect.New(resType.Type).Interface()
+		if !isEmptyType(resInstance) {
+			err := readAndParseBody(w, r, resInstance, cfg.MaxRequestSize)
 			if err != nil {
-				cfg.ErrorHook(err)
+				cfg.respondError(w, err)
+				return err
 			}
-			return
 		}
-		err = respond(w, out)
+		valueOf := reflect.Indirect(reflect.ValueOf(&in))
+		valueOf.FieldByName("PathValues").Set(reflect.ValueOf(extractPathValues(r)))
+		valueOf.FieldByName("Context").Set(reflect.ValueOf(r.Context()))
+		valueOf.FieldByName("Parameters").Set(reflect.ValueOf(mapFlatten(r.URL.Query())))
+		valueOf.FieldByName("Headers").Set(reflect.ValueOf(mapFlatten(r.Header)))
+		valueOf.FieldByName("Header").Set(reflect.ValueOf(r.Header))
+		valueOf.FieldByName("Cookies").Set(reflect.ValueOf(r.Cookies()))
+		valueOf.FieldByName("Body").Set(reflect.ValueOf(resInstance).Elem())
+	} else if !isEmptyType(in) {
+		err := readAndParseBody(w, r, &in, cfg.MaxRequestSize)
 		if err != nil {
-			cfg.ErrorHook(err)
+			cfg.respondError(w, err)
+			return err
+		}
+	}
+
+	out, err := apply(in)
+	if err != nil {
+		status := 500
+		if erro, ok := err.(*Error); ok {
+			status = erro.Status
+		}
+		rerr := respondError(w, status, err)
+		if rerr != nil {
+			cfg.errorHook(rerr)
 		}
+		return err
+	}
+	if isEventStream(out) {
+		err = respondEvents(w, r, out, cfg.Heartbeat)
+	} else {
+		err = respond(w, out, respondConfig{
+			Accept:          headerOf(r, "Accept"),
+			AcceptEncoding:  headerOf(r, "Accept-Encoding"),
+			CompressMinSize: cfg.compressMinSize(),
+		})
 	}
+	if err != nil {
+		cfg.errorHook(err)
+	}
+	return err
 }
 
 func ToHandlerFuncEmptyOut[In any](consume ConsumeFunc[In]) http.HandlerFunc {
@@ -129,18 +216,42 @@ func ToHandlerFuncEmptyIn[Out any](supply SupplyFunc[Out]) http.HandlerFunc {
 	})
 }
 
-func readAndParseBody(r *http.Request, in any) error {
+func readAndParseBody(w http.ResponseWriter, r *http.Request, in any, maxSize int64) error {
+	err := decodeRequest(r)
+	if err != nil {
+		return err
+	}
+	if maxSize > 0 {
+		// limits the decoded body, guarding against compression bombs too.
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	}
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
 		return err
 	}
-	err = parseBodyInto(reqBody, in)
+	err = parseBodyAs(reqBody, in, r.Header.Get("Content-Type"))
 	if err != nil {
 		return fmt.Errorf("parse request body: %s", err)
 	}
 	return nil
 }
 
+// routePattern returns the pattern of ServeMux matching the request, empty before go1.22.
+func routePattern(r *http.Request) string {
+	pattern := reflect.ValueOf(r).Elem().FieldByName("Pattern")
+	if !pattern.IsValid() {
+		return ""
+	}
+	return pattern.String()
+}
+
+func headerOf(r *http.Request, name string) string {
+	if r == nil {
+		return ""
+	}
+	return r.Header.Get(name)
+}
+
 func extractPathValues(r *http.Request) map[string]string {
 	if !isGo23AndAbove() || r == nil {
 		return map[string]string{}
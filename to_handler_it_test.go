@@ -8,8 +8,7 @@ import (
 )
 
 func TestToHandlerFunc(t *testing.T) {
-	mock = false
-	defer func() { mock = true }()
+	withoutMock(t)
 	type Pet struct {
 		Id    string
 		Name  string
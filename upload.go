@@ -0,0 +1,88 @@
+package fetch
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// readerBody streams the reader as the request body.
+// The request can be retried only if the reader is io.ReaderAt and io.Seeker,
+// every attempt reads its own section of the reader, e.g. *os.File, *bytes.Reader or *strings.Reader.
+func readerBody(r io.Reader) *requestBody {
+	rb := &requestBody{contentType: "application/octet-stream"}
+	if seeker, ok := r.(io.Seeker); ok {
+		if start, length, err := seekLength(seeker); err == nil {
+			rb.length = length
+			if readerAt, ok := r.(io.ReaderAt); ok {
+				rb.replayable = true
+				rb.open = func() (io.Reader, error) {
+					return io.NewSectionReader(readerAt, start, length), nil
+				}
+				return rb
+			}
+		}
+	}
+	// the reader belongs to the caller, the transport mustn't close it.
+	body := struct{ io.Reader }{r}
+	opened := false
+	rb.open = func() (io.Reader, error) {
+		if opened {
+			return nil, errors.New("the body can't be read again, it isn't io.ReaderAt and io.Seeker")
+		}
+		opened = true
+		return body, nil
+	}
+	return rb
+}
+
+// seekLength returns the current position and the remaining length of the seeker.
+func seekLength(s io.Seeker) (int64, int64, error) {
+	start, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, 0, err
+	}
+	_, err = s.Seek(start, io.SeekStart)
+	return start, end - start, err
+}
+
+// withUploadProgress reports reading of the request body, including the retried ones.
+func withUploadProgress(req *http.Request, progress func(sent, total int64)) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	total := req.ContentLength
+	if total == 0 {
+		total = -1
+	}
+	req.Body = &progressBody{ReadCloser: req.Body, total: total, progress: progress}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &progressBody{ReadCloser: body, total: total, progress: progress}, nil
+		}
+	}
+}
+
+type progressBody struct {
+	io.ReadCloser
+	sent     int64
+	total    int64
+	progress func(sent, total int64)
+}
+
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.sent += int64(n)
+		b.progress(b.sent, b.total)
+	}
+	return n, err
+}
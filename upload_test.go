@@ -0,0 +1,86 @@
+package fetch
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newUploadMock() (*Mock, *atomic.Int32) {
+	var calls atomic.Int32
+	m := &Mock{}
+	m.On("PUT", "files.io/upload").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			return mockResponse(req, 503, nil, nil), nil
+		}
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		return mockResponse(req, 200, nil, []byte(req.Header.Get("Content-Type")+" "+strconv.FormatInt(req.ContentLength, 10)+" "+string(b))), nil
+	})
+	return m, &calls
+}
+
+func TestUpload_Seeker(t *testing.T) {
+	m, calls := newUploadMock()
+	c := &Client{HttpClient: m.HttpClient()}
+	r := strings.NewReader("skip:hello")
+	r.Seek(5, io.SeekStart)
+	var sent, total int64
+	res, err := PutWith[string](c, "files.io/upload", r, Config{
+		Retry: Retry{Attempts: 2, Backoff: time.Millisecond},
+		UploadProgress: func(s, t int64) {
+			sent, total = s, t
+		},
+	})
+	assert(t, err, nil)
+	assert(t, res, "application/octet-stream 5 hello")
+	assert(t, calls.Load(), int32(2))
+	assert(t, sent, int64(5))
+	assert(t, total, int64(5))
+}
+
+func TestUpload_Reader(t *testing.T) {
+	m, calls := newUploadMock()
+	c := &Client{HttpClient: m.HttpClient()}
+	retry := Retry{Attempts: 2, Backoff: time.Millisecond}
+
+	_, err := PutWith[string](c, "files.io/upload", io.MultiReader(strings.NewReader("hello")), Config{Retry: retry})
+	assertNotNil(t, err)
+	assert(t, err.(*Error).Status, 503)
+	assert(t, calls.Load(), int32(1))
+
+	res, err := PutWith[string](c, "files.io/upload", io.MultiReader(strings.NewReader("hello")), Config{
+		Retry:         retry,
+		ContentLength: 5,
+		Headers:       map[string]string{"Content-Type": "text/plain"},
+	})
+	assert(t, err, nil)
+	assert(t, res, "text/plain 5 hello")
+}
+
+func TestUpload_SeekerLogged(t *testing.T) {
+	m := &Mock{}
+	m.On("PUT", "files.io/upload").ReplyFunc(func(req *http.Request) (*http.Response, error) {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		return mockResponse(req, 200, nil, b), nil
+	})
+	logger, buf := newTestLogger()
+	c := &Client{HttpClient: m.HttpClient(), Logger: logger, LogOptions: LogOptions{BodySize: 3}}
+
+	// the logger peeks the body through GetBody, which mustn't shorten the upload.
+	res, err := PutWith[string](c, "files.io/upload", strings.NewReader("hello world"))
+	assert(t, err, nil)
+	assert(t, res, "hello world")
+	if !strings.Contains(buf.String(), "request_body=hel...") {
+		t.Errorf("wrong log: %s", buf.String())
+	}
+}
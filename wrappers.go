@@ -2,6 +2,7 @@ package fetch
 
 import (
 	"context"
+	"net/http"
 	"reflect"
 	"strings"
 )
@@ -24,8 +25,19 @@ e.g.
 	fmt.Println(res.Body.FirstName)
 */
 type Response[T any] struct {
-	Status  int
+	Status int
+	// HTTP headers with the last value of each.
 	Headers map[string]string
+	// HTTP headers with all the values e.g. of Set-Cookie.
+	// Takes precedence over Headers when responding in ToHandlerFunc.
+	Header http.Header
+	// Cookies set by the response.
+	// In ToHandlerFunc they are sent as Set-Cookie headers.
+	Cookies []*http.Cookie
+	// Cached is true if the response was served by Cache, including the ones revalidated with the server.
+	Cached bool
+	// Timings of the request if Config.Trace is set, nil otherwise.
+	Timings *Timings
 	Body    T
 }
 
@@ -62,8 +74,12 @@ type Request[T any] struct {
 	PathValues map[string]string
 	// URL parameters.
 	Parameters map[string]string
-	// HTTP headers.
+	// HTTP headers with the last value of each.
 	Headers map[string]string
+	// HTTP headers with all the values.
+	Header http.Header
+	// Cookies sent with the request.
+	Cookies []*http.Cookie
 	Body    T
 }
 
@@ -86,6 +102,10 @@ func (r Request[T]) WithParameter(name, value string) Request[T] {
 }
 
 func (r Request[T]) WithHeader(name, value string) Request[T] {
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	r.Header.Set(name, value)
 	if r.Headers == nil {
 		r.Headers = map[string]string{name: value}
 		return r
@@ -94,6 +114,11 @@ func (r Request[T]) WithHeader(name, value string) Request[T] {
 	return r
 }
 
+func (r Request[T]) WithCookie(c *http.Cookie) Request[T] {
+	r.Cookies = append(r.Cookies, c)
+	return r
+}
+
 // Empty represents an empty response or request body, skipping JSON handling.
 // Can be used with the wrappers Response and Request or to fit the signature of ApplyFunc.
 type Empty struct{}